@@ -0,0 +1,198 @@
+package newstojson
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// CourseType is a kind of degree, the tcs parameter of the course lists of
+// the department sites
+type CourseType string
+
+// Course types, in the order the sites list them
+const (
+	CourseBachelor        CourseType = "N"  // Laurea
+	CourseMaster          CourseType = "MA" // Laurea magistrale
+	CourseSingleCycle     CourseType = "mu" // Laurea magistrale a ciclo unico
+	CourseSpecialisation  CourseType = "SP" // Scuola di specializzazione
+	CoursePhD             CourseType = "R"  // Dottorato di ricerca
+	CourseTraining        CourseType = "F"  // Corso di perfezionamento e master
+	CourseTeacherTraining CourseType = "T"  // Tirocinio formativo attivo
+)
+
+var courseTypes = []CourseType{
+	CourseBachelor,
+	CourseMaster,
+	CourseSingleCycle,
+	CourseSpecialisation,
+	CoursePhD,
+	CourseTraining,
+	CourseTeacherTraining,
+}
+
+var courseDescriptions = map[CourseType]string{
+	CourseBachelor:        "bachelor's degree",
+	CourseMaster:          "master's degree",
+	CourseSingleCycle:     "single-cycle master's degree",
+	CourseSpecialisation:  "specialisation school",
+	CoursePhD:             "PhD programme",
+	CourseTraining:        "professional training course",
+	CourseTeacherTraining: "teacher training",
+}
+
+// AllCourseTypes returns every course type, in the order the sites list them
+func AllCourseTypes() []CourseType {
+	return append([]CourseType(nil), courseTypes...)
+}
+
+// ParseCourseType returns the course type of the tcs code s, like MA
+func ParseCourseType(s string) (CourseType, error) {
+	t := CourseType(s)
+	if _, ok := courseDescriptions[t]; !ok {
+		return "", fmt.Errorf("newstojson: unknown course type %q", s)
+	}
+	return t, nil
+}
+
+// Description returns the English name of the course type, like "master's
+// degree"
+func (t CourseType) Description() string {
+	return courseDescriptions[t]
+}
+
+// Degree is a degree course listed by a department site
+type Degree struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Type        CourseType `json:"type"`
+	Host        string     `json:"host"`
+	NewsPageURL string     `json:"news_page_url"`         // Like host/?ent=avvisoin&cs=ID
+	ValidUntil  string     `json:"valid_until,omitempty"` // Last academic year of a discontinued degree, like 2014/2015
+}
+
+// untilRegexp matches the academic year of a discontinued degree
+var untilRegexp = regexp.MustCompile(`\s*\((?:[^)]*\s)?until\s+([^)]*)\)`)
+
+// Catalog returns the degrees of a department host, like www.di.univr.it,
+// including the discontinued ones. Clients with CourseTypes set list only
+// those types.
+func Catalog(host string) ([]Degree, error) {
+	return DefaultClient.Catalog(host)
+}
+
+// Catalog is like the package level Catalog but uses c for the requests
+func (c *Client) Catalog(host string) ([]Degree, error) {
+	return c.CatalogContext(context.Background(), host)
+}
+
+// CatalogContext is like Catalog but stops as soon as ctx is done
+func (c *Client) CatalogContext(ctx context.Context, host string) ([]Degree, error) {
+	return c.catalog(ctx, host, nil)
+}
+
+func (c *Client) courseTypes() []CourseType {
+	if c == nil || len(c.CourseTypes) == 0 {
+		return courseTypes
+	}
+	return c.CourseTypes
+}
+
+// catalog fetches the degree list of every course type of host, concurrently,
+// and joins the results in the order of c.courseTypes
+func (c *Client) catalog(ctx context.Context, host string, warnings *[]Warning) ([]Degree, error) {
+	profile := c.ProfileFor(host)
+	types := c.courseTypes()
+	lists := make([][]Degree, len(types))
+	listWarnings := make([][]Warning, len(types))
+	err := c.forEach(ctx, len(types), func(ctx context.Context, i int) error {
+		var err error
+		lists[i], err = c.courseList(ctx, host+"/?ent=cs&tcs="+string(types[i]), profile, &listWarnings[i])
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var res []Degree
+	for i := range lists {
+		res = append(res, lists[i]...)
+		for _, w := range listWarnings[i] {
+			addWarning(warnings, w)
+		}
+	}
+	return res, nil
+}
+
+// courseList returns the degrees listed by the page at urlString, a host
+// followed by /?ent=cs&tcs=<type>, laid out as described by profile
+func (c *Client) courseList(ctx context.Context, urlString string, profile SiteProfile, warnings *[]Warning) ([]Degree, error) {
+	var res []Degree
+
+	urlString = "http://" + urlString
+
+	rootURL, err := url.Parse(urlString)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := c.document(ctx, urlString)
+	if err != nil {
+		return nil, err
+	}
+
+	list := doc.Find(profile.CourseList)
+	if list.Length() == 0 {
+		return nil, layoutChanged(urlString, profile.CourseList)
+	}
+	if profile.CourseListScope != "" {
+		list = list.Find(profile.CourseListScope).First()
+	}
+	links := list.Find(profile.CourseLinks)
+
+	courseType := CourseType(rootURL.Query().Get("tcs"))
+	links.Each(func(i int, s *goquery.Selection) {
+		idString, idBool := s.Attr("href")
+		if !idBool {
+			return
+		}
+		id, ok := getIDFromString(idString)
+		if !ok {
+			addWarning(warnings, Warning{Field: "degree_ids", Message: "course link without id skipped", Raw: idString})
+			return
+		}
+
+		degree := Degree{
+			ID:          id,
+			Name:        removeExtraSpaces(s.Text()),
+			Type:        courseType,
+			Host:        rootURL.Host,
+			NewsPageURL: rootURL.Host + "/?ent=avvisoin&cs=" + strconv.Itoa(id),
+		}
+		if m := untilRegexp.FindStringSubmatch(degree.Name); m != nil {
+			degree.ValidUntil = m[1]
+			degree.Name = strings.TrimSpace(untilRegexp.ReplaceAllString(degree.Name, ""))
+		}
+		res = append(res, degree)
+	})
+
+	return res, nil
+}
+
+// newsPageURLs returns the news pages of degrees, leaving out the
+// discontinued ones if skipDiscontinued is true
+func newsPageURLs(degrees []Degree, skipDiscontinued bool) []string {
+	var res []string
+	for _, degree := range degrees {
+		if skipDiscontinued && degree.ValidUntil != "" {
+			continue
+		}
+		res = append(res, degree.NewsPageURL)
+	}
+	return res
+}
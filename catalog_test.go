@@ -0,0 +1,91 @@
+package newstojson
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestCatalog(t *testing.T) {
+	c := newFixtureClient(t)
+	res, err := c.Catalog("www.medicina.univr.it")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const host = "www.medicina.univr.it"
+	expected := []Degree{
+		{101, "Nursing (Verona)", CourseBachelor, host, host + "/?ent=avvisoin&cs=101", ""},
+		{102, "Physiotherapy (Verona)", CourseBachelor, host, host + "/?ent=avvisoin&cs=102", ""},
+		{95, "Dietistics", CourseBachelor, host, host + "/?ent=avvisoin&cs=95", "2014/2015"},
+		{103, "Nursing and midwifery sciences", CourseMaster, host, host + "/?ent=avvisoin&cs=103", ""},
+		{104, "Medicine and surgery", CourseSingleCycle, host, host + "/?ent=avvisoin&cs=104", ""},
+		{105, "Cardiology", CourseSpecialisation, host, host + "/?ent=avvisoin&cs=105", ""},
+		{106, "Paediatrics", CourseSpecialisation, host, host + "/?ent=avvisoin&cs=106", ""},
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("Expected %v, got %v", expected, res)
+	}
+
+	res, err = c.Catalog("www.di.univr.it")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages, err := c.NewsPages("www.di.univr.it")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != len(pages) {
+		t.Fatal("Expected a degree for each news page, got", len(res), len(pages))
+	}
+	for i, degree := range res {
+		if degree.NewsPageURL != pages[i] || degree.Host != "www.di.univr.it" || degree.Name == "" {
+			t.Errorf("%d: unexpected degree %+v", i, degree)
+		}
+	}
+	if res[0].ID != 419 || res[0].Name != "Bachelor's degree in Applied Mathematics" || res[0].Type != CourseBachelor {
+		t.Errorf("Unexpected first degree %+v", res[0])
+	}
+}
+
+func TestCatalogCourseTypes(t *testing.T) {
+	c := newFixtureClient(t)
+	var requested []string
+	f := c.Fetcher
+	c.Fetcher = FetcherFunc(func(req *http.Request) (*http.Response, error) {
+		requested = append(requested, req.URL.RawQuery)
+		return f.Do(req)
+	})
+	c.CourseTypes = []CourseType{CourseSpecialisation}
+
+	res, err := c.Catalog("www.medicina.univr.it")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 || res[0].ID != 105 || res[1].ID != 106 {
+		t.Error("Expected the specialisation schools 105 and 106, got", res)
+	}
+	if !reflect.DeepEqual(requested, []string{"ent=cs&tcs=SP"}) {
+		t.Error("Unexpected requests", requested)
+	}
+}
+
+func TestParseCourseType(t *testing.T) {
+	for _, ct := range AllCourseTypes() {
+		res, err := ParseCourseType(string(ct))
+		if err != nil || res != ct {
+			t.Errorf("%s: got %q, %v", ct, res, err)
+		}
+		if ct.Description() == "" {
+			t.Errorf("%s: no description", ct)
+		}
+	}
+	if CourseSingleCycle.Description() != "single-cycle master's degree" {
+		t.Error("Unexpected description", CourseSingleCycle.Description())
+	}
+
+	_, err := ParseCourseType("X")
+	if err == nil {
+		t.Error("Expected error but got nil")
+	}
+}
@@ -0,0 +1,256 @@
+package newstojson
+
+import (
+	"context"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	rss "github.com/jteeuwen/go-pkg-rss"
+)
+
+// Fetcher performs the HTTP requests made by the package. *http.Client
+// satisfies it, so timeouts, proxies and custom transports are configured on
+// the http.Client passed to NewClient.
+type Fetcher interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// FetcherFunc adapts an ordinary function to the Fetcher interface
+type FetcherFunc func(req *http.Request) (*http.Response, error)
+
+// Do calls f(req)
+func (f FetcherFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Client routes every network call of the package through its Fetcher
+type Client struct {
+	Fetcher Fetcher
+
+	// SkipPreviews disables the download of the attachment previews
+	SkipPreviews bool
+
+	// Language is the lang parameter used to request the news pages, "eng"
+	// if empty
+	Language string
+
+	// Languages are the languages, besides Language, whose pages are
+	// fetched to fill the Translations of a news
+	Languages []string
+
+	// ContentPolicy sanitizes the HTML of the notice body, ContentHTML, and
+	// the links of ContentMarkdown. The package level ContentPolicy is used
+	// if it is nil.
+	ContentPolicy *Policy
+
+	// PreviewPolicy sanitizes the attachment previews. The package level
+	// PreviewPolicy is used if it is nil.
+	PreviewPolicy *Policy
+
+	// Strict turns the first warning of a news into an error
+	Strict bool
+
+	// Logger receives a debug record for every request and a warning record
+	// for every warning of a news. Nothing is logged if it is nil.
+	Logger *slog.Logger
+
+	// Concurrency is the number of pages fetched at the same time while
+	// looking for the degrees of a news, 1 if zero
+	Concurrency int
+
+	// HostInterval is the minimum time between the start of two requests to
+	// the same host
+	HostInterval time.Duration
+
+	// Profiles describe the layout of sites, they are looked up by ProfileFor
+	// before the built-in ones
+	Profiles []SiteProfile
+
+	// CourseTypes restricts the crawl of the degrees of a host, by Catalog
+	// and SetIDsCourses, to these types. Empty means all of them.
+	CourseTypes []CourseType
+
+	// History makes SetIDsCourses look for the news in the whole notice list
+	// of every degree, following its pages, instead of the five most recent
+	// notices
+	History bool
+
+	// HistoryPages limits the pages of a notice list read in History mode,
+	// 0 means all of them
+	HistoryPages int
+
+	// HistorySince stops the History mode at the first page of a notice list
+	// whose notices are all older than it
+	HistorySince time.Time
+
+	// Index, if set, is used by SetIDsCourses instead of crawling the host
+	// for every news
+	Index *DegreeIndex
+
+	mu   sync.Mutex
+	next map[string]time.Time // earliest start of the next request per host
+}
+
+// DefaultClient is the Client used by the package level functions
+var DefaultClient = &Client{Fetcher: http.DefaultClient}
+
+// NewClient returns a Client that uses f for all the requests. A nil f means
+// http.DefaultClient.
+func NewClient(f Fetcher) *Client {
+	return &Client{Fetcher: f}
+}
+
+func (c *Client) fetcher() Fetcher {
+	if c == nil || c.Fetcher == nil {
+		return http.DefaultClient
+	}
+	return c.Fetcher
+}
+
+func (c *Client) language() string {
+	if c.Language == "" {
+		return "eng"
+	}
+	return c.Language
+}
+
+func (c *Client) contentPolicy() *Policy {
+	if c == nil || c.ContentPolicy == nil {
+		return &ContentPolicy
+	}
+	return c.ContentPolicy
+}
+
+func (c *Client) previewPolicy() *Policy {
+	if c == nil || c.PreviewPolicy == nil {
+		return &PreviewPolicy
+	}
+	return c.PreviewPolicy
+}
+
+// log writes a record to c.Logger, if set
+func (c *Client) log(ctx context.Context, level slog.Level, msg string, args ...interface{}) {
+	if c == nil || c.Logger == nil {
+		return
+	}
+	c.Logger.Log(ctx, level, msg, args...)
+}
+
+// get issues a GET request for urlString bound to ctx. Responses other than
+// 200 OK are returned as *StatusError.
+func (c *Client) get(ctx context.Context, urlString string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", urlString, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = c.wait(ctx, req.URL.Host)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	resp, err := c.fetcher().Do(req)
+	if err != nil {
+		c.log(ctx, slog.LevelDebug, "request failed", "host", req.URL.Host, "url", urlString, "error", err)
+		return nil, err
+	}
+	c.log(ctx, slog.LevelDebug, "request", "host", req.URL.Host, "url", urlString,
+		"status", resp.StatusCode, "duration", time.Since(start))
+	if resp.Request == nil {
+		// Fetchers other than http.Client may leave it out
+		resp.Request = req
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, &StatusError{URL: urlString, StatusCode: resp.StatusCode}
+	}
+	return resp, nil
+}
+
+// document fetches urlString and parses the response as HTML
+func (c *Client) document(ctx context.Context, urlString string) (*goquery.Document, error) {
+	resp, err := c.get(ctx, urlString)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	doc.Url = resp.Request.URL
+	return doc, nil
+}
+
+// readAll fetches urlString and returns the response body
+func (c *Client) readAll(ctx context.Context, urlString string) ([]byte, error) {
+	resp, err := c.get(ctx, urlString)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return ioutil.ReadAll(resp.Body)
+}
+
+// =============================================================================
+// Client parse functions
+// =============================================================================
+
+// Parse is like the package level Parse but uses c for the requests
+func (c *Client) Parse(rssitem *rss.Item) (*News, error) {
+	return c.ParseContext(context.Background(), rssitem)
+}
+
+// ParseContext is like Parse but stops as soon as ctx is done
+func (c *Client) ParseContext(ctx context.Context, rssitem *rss.Item) (*News, error) {
+	news, err := newsFromItem(rssitem)
+	if err != nil {
+		return nil, err
+	}
+
+	// Retrive other infos
+	err = c.GetContentFromURLContext(ctx, news)
+	if err != nil {
+		return nil, err
+	}
+
+	return news, nil
+}
+
+// ParseFromLink is like the package level ParseFromLink but uses c for the
+// requests
+func (c *Client) ParseFromLink(link *url.URL) (*News, error) {
+	return c.ParseFromLinkContext(context.Background(), link)
+}
+
+// ParseFromLinkContext is like ParseFromLink but stops as soon as ctx is done
+func (c *Client) ParseFromLinkContext(ctx context.Context, link *url.URL) (*News, error) {
+	if link == nil {
+		return nil, ErrInvalidNewsURL
+	}
+	news := newsFromLink(link)
+
+	// Retrive other infos
+	err := c.GetContentFromURLContext(ctx, news)
+	if err != nil {
+		return nil, err
+	}
+
+	return news, nil
+}
+
+// CompleteParse retrive all the informations of item using c
+func (c *Client) CompleteParse(item *News) error {
+	return c.CompleteParseContext(context.Background(), item)
+}
+
+// CompleteParseContext is like CompleteParse but stops as soon as ctx is done
+func (c *Client) CompleteParseContext(ctx context.Context, item *News) error {
+	// Get all IDs courses
+	return c.SetIDsCoursesContext(ctx, item)
+}
@@ -0,0 +1,158 @@
+package newstojson
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// htmlResponse returns a fake 200 response with body as content
+func htmlResponse(req *http.Request, body string) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
+
+func TestClientFetcher(t *testing.T) {
+	var requested []string
+	c := NewClient(FetcherFunc(func(req *http.Request) (*http.Response, error) {
+		requested = append(requested, req.URL.String())
+		return htmlResponse(req, `<div id="contenutoPagina"><div><dl>
+			<dt><a href="/?ent=cs&amp;id=385">Bioinformatics</a></dt>
+			<dt><a href="/?ent=cs&amp;id=419">Biotechnology</a></dt>
+		</dl></div></div>`), nil
+	}))
+
+	res, err := c.NewsPageLinksFromURLCorso("www.dbt.univr.it/?ent=cs&tcs=N")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	correctUrls := []string{
+		"www.dbt.univr.it/?ent=avvisoin&cs=385",
+		"www.dbt.univr.it/?ent=avvisoin&cs=419",
+	}
+	if !reflect.DeepEqual(correctUrls, res) {
+		t.Error("Expected", correctUrls, "got", res)
+	}
+	if !reflect.DeepEqual(requested, []string{"http://www.dbt.univr.it/?ent=cs&tcs=N"}) {
+		t.Error("Unexpected requests", requested)
+	}
+}
+
+func TestClientFetcherWithoutRequest(t *testing.T) {
+	c := NewClient(FetcherFunc(func(req *http.Request) (*http.Response, error) {
+		resp := htmlResponse(req, `<div id="contenutoPagina"><div><dl>
+			<dt><a href="/?ent=cs&amp;id=385">Bioinformatics</a></dt>
+		</dl></div></div>`)
+		resp.Request = nil
+		return resp, nil
+	}))
+
+	res, err := c.NewsPageLinksFromURLCorso("www.dbt.univr.it/?ent=cs&tcs=N")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res, []string{"www.dbt.univr.it/?ent=avvisoin&cs=385"}) {
+		t.Error("Unexpected links", res)
+	}
+}
+
+func TestParseFromLinkContextCanceled(t *testing.T) {
+	c := NewClient(FetcherFunc(func(req *http.Request) (*http.Response, error) {
+		if err := req.Context().Err(); err != nil {
+			return nil, err
+		}
+		return htmlResponse(req, "<h1>Title</h1>"), nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	link, _ := url.Parse("http://www.di.univr.it/?ent=avviso&id=119016")
+	_, err := c.ParseFromLinkContext(ctx, link)
+	if !errors.Is(err, context.Canceled) {
+		t.Error("Expected context.Canceled, got", err)
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	c := NewClient(FetcherFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusNotFound,
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+			Request:    req,
+		}, nil
+	}))
+	link, _ := url.Parse("http://www.di.univr.it/?ent=avviso&dest=&id=119016&lang=eng")
+
+	item, err := c.ParseHTMLContext(context.Background(), strings.NewReader(testNewsPage), link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(item.Warnings) != 1 {
+		t.Fatal("Expected 1 warning, got", item.Warnings)
+	}
+	w := item.Warnings[0]
+	if w.Field != "attachments" || w.Raw != "http://www.di.univr.it/?ent=avviso&id=119016&preview=1" {
+		t.Error("Unexpected warning", w)
+	}
+
+	c.Strict = true
+	_, err = c.ParseHTMLContext(context.Background(), strings.NewReader(testNewsPage), link)
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Field != "attachments" {
+		t.Error("Expected a FieldError for attachments, got", err)
+	}
+	if !errors.Is(err, ErrNotFound) {
+		t.Error("Expected the FieldError to wrap ErrNotFound, got", err)
+	}
+
+	item, err = ParseHTML(strings.NewReader(testNewsPage), link)
+	if err != nil || item.Warnings != nil {
+		t.Error("Expected no warnings, got", err, item.Warnings)
+	}
+}
+
+func TestClientLogger(t *testing.T) {
+	var buf bytes.Buffer
+	c := NewClient(FetcherFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusNotFound,
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+			Request:    req,
+		}, nil
+	}))
+	c.Logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	link, _ := url.Parse("http://www.di.univr.it/?ent=avviso&dest=&id=119016&lang=eng")
+	_, err := c.ParseHTMLContext(context.Background(), strings.NewReader(testNewsPage), link)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatal("Expected 2 records, got", lines)
+	}
+	for _, attr := range []string{"level=DEBUG", "msg=request", "host=www.di.univr.it", "status=404"} {
+		if !strings.Contains(lines[0], attr) {
+			t.Errorf("Expected %s in %q", attr, lines[0])
+		}
+	}
+	for _, attr := range []string{"level=WARN", "news_id=119016", "field=attachments", "host=www.di.univr.it"} {
+		if !strings.Contains(lines[1], attr) {
+			t.Errorf("Expected %s in %q", attr, lines[1])
+		}
+	}
+}
@@ -0,0 +1,354 @@
+// Command newstojson prints the news of the UniVR department sites as JSON.
+//
+// Usage:
+//
+//	newstojson link [flags] <url>...
+//	newstojson feed [flags] <file-or-url>
+//	newstojson courses [flags] <host>
+//	newstojson degrees [flags] <host>
+//
+// link parses the news at the given addresses, feed parses every item of an
+// RSS or Atom feed, courses lists the news pages of the degrees of a department
+// and degrees lists the degrees themselves.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/giovanni-liboni/newstojson"
+)
+
+const usage = `usage: newstojson <command> [flags] <args>
+
+commands:
+  link <url>...          parse the news at the given addresses
+  feed <file-or-url>     parse every item of an RSS or Atom feed
+  courses <host>         list the news pages of the degrees of a host
+  degrees <host>         list the degrees of a host
+
+Run 'newstojson <command> -h' for the flags of a command.
+`
+
+// options holds the flags shared by all the commands
+type options struct {
+	lang     string
+	trans    string
+	complete bool
+	previews bool
+	strict   bool
+	verbose  bool
+	workers  int
+	interval time.Duration
+	history  bool
+	pages    int
+	since    string
+	types    string
+	profiles string
+	format   string
+	timeout  time.Duration
+
+	sinceTime    time.Time
+	courseTypes  []newstojson.CourseType
+	siteProfiles []newstojson.SiteProfile
+
+	fetcher newstojson.Fetcher // nil means an http.Client with timeout
+	stdout  io.Writer
+	stderr  io.Writer
+}
+
+func (o *options) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.lang, "lang", "eng", "language of the news pages (eng or ita)")
+	fs.StringVar(&o.trans, "translations", "", "comma separated languages to fetch into translations, like eng,ita")
+	fs.BoolVar(&o.complete, "complete", false, "run CompleteParse to set the degree IDs")
+	fs.BoolVar(&o.previews, "previews", true, "download the attachment previews")
+	fs.BoolVar(&o.strict, "strict", false, "fail on the first parse warning")
+	fs.BoolVar(&o.verbose, "v", false, "log every request to stderr")
+	fs.IntVar(&o.workers, "concurrency", 4, "pages fetched at the same time by -complete")
+	fs.DurationVar(&o.interval, "interval", 0, "minimum time between two requests to the same host")
+	fs.BoolVar(&o.history, "history", false, "look for the degrees in the whole notice lists, not only the last five notices")
+	fs.IntVar(&o.pages, "history-pages", 0, "pages of a notice list read by -history, 0 means all")
+	fs.StringVar(&o.types, "types", "", "comma separated course types to crawl, like N,MA (default all)")
+	fs.StringVar(&o.profiles, "profiles", "", "JSON file with the layouts of the sites")
+	fs.StringVar(&o.since, "since", "", "stop -history at notices older than this date (2006-01-02)")
+	fs.StringVar(&o.format, "format", "json", "output format: json or ndjson")
+	fs.DurationVar(&o.timeout, "timeout", 30*time.Second, "timeout of each HTTP request")
+}
+
+func (o *options) client() *newstojson.Client {
+	fetcher := o.fetcher
+	if fetcher == nil {
+		fetcher = &http.Client{Timeout: o.timeout}
+	}
+	c := newstojson.NewClient(fetcher)
+	c.Language = o.lang
+	if o.trans != "" {
+		c.Languages = strings.Split(o.trans, ",")
+	}
+	c.SkipPreviews = !o.previews
+	c.Strict = o.strict
+	c.Concurrency = o.workers
+	c.HostInterval = o.interval
+	c.History = o.history
+	c.HistoryPages = o.pages
+	c.HistorySince = o.sinceTime
+	c.CourseTypes = o.courseTypes
+	c.Profiles = o.siteProfiles
+	// Crawl the degrees of each host once for all the news
+	c.Index = newstojson.NewDegreeIndex(0)
+	level := slog.LevelWarn
+	if o.verbose {
+		level = slog.LevelDebug
+	}
+	c.Logger = slog.New(slog.NewTextHandler(o.stderr, &slog.HandlerOptions{Level: level}))
+	return c
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var run func(ctx context.Context, opts *options, args []string) error
+	switch os.Args[1] {
+	case "link":
+		run = runLink
+	case "feed":
+		run = runFeed
+	case "courses":
+		run = runCourses
+	case "degrees":
+		run = runDegrees
+	case "-h", "-help", "--help", "help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "newstojson: unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet("newstojson "+os.Args[1], flag.ExitOnError)
+	opts, err := parseFlags(fs, os.Args[2:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	opts.stdout, opts.stderr = os.Stdout, os.Stderr
+
+	err = run(context.Background(), opts, fs.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "newstojson:", err)
+		os.Exit(1)
+	}
+}
+
+// parseFlags parses the flags of a command from args into new options
+func parseFlags(fs *flag.FlagSet, args []string) (*options, error) {
+	opts := new(options)
+	opts.register(fs)
+	err := fs.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.format != "json" && opts.format != "ndjson" {
+		return nil, fmt.Errorf("newstojson: unknown format %q", opts.format)
+	}
+	if opts.since != "" {
+		opts.sinceTime, err = time.Parse("2006-01-02", opts.since)
+		if err != nil {
+			return nil, fmt.Errorf("newstojson: invalid -since %q", opts.since)
+		}
+	}
+	if opts.types != "" {
+		for _, code := range strings.Split(opts.types, ",") {
+			ct, err := newstojson.ParseCourseType(strings.TrimSpace(code))
+			if err != nil {
+				return nil, err
+			}
+			opts.courseTypes = append(opts.courseTypes, ct)
+		}
+	}
+
+	if opts.profiles != "" {
+		f, err := os.Open(opts.profiles)
+		if err != nil {
+			return nil, fmt.Errorf("newstojson: %v", err)
+		}
+		opts.siteProfiles, err = newstojson.LoadProfiles(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return opts, nil
+}
+
+func runLink(ctx context.Context, opts *options, args []string) error {
+	if len(args) == 0 {
+		return errors.New("link: missing url")
+	}
+	c := opts.client()
+
+	var res []*newstojson.News
+	for _, arg := range args {
+		link, err := url.Parse(arg)
+		if err != nil {
+			return err
+		}
+		item, err := c.ParseFromLinkContext(ctx, link)
+		if err != nil {
+			return fmt.Errorf("%s: %v", arg, err)
+		}
+		if opts.complete {
+			err = c.CompleteParseContext(ctx, item)
+			if err != nil {
+				return fmt.Errorf("%s: %v", arg, err)
+			}
+		}
+		res = append(res, item)
+	}
+
+	if len(res) == 1 && opts.format == "json" {
+		return writeJSON(opts.stdout, res[0])
+	}
+	return writeNews(opts.stdout, opts.format, res)
+}
+
+func runFeed(ctx context.Context, opts *options, args []string) error {
+	if len(args) != 1 {
+		return errors.New("feed: expected a file or url")
+	}
+	c := opts.client()
+
+	content, err := readFeed(args[0], opts.timeout)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.ParseFeedContext(ctx, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	if opts.complete {
+		for _, item := range res {
+			err = c.CompleteParseContext(ctx, item)
+			if err != nil {
+				return fmt.Errorf("%s: %v", item.Link, err)
+			}
+		}
+	}
+
+	return writeNews(opts.stdout, opts.format, res)
+}
+
+func runCourses(ctx context.Context, opts *options, args []string) error {
+	if len(args) != 1 {
+		return errors.New("courses: expected a host")
+	}
+	c := opts.client()
+
+	pages, err := c.NewsPagesContext(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	if opts.format == "ndjson" {
+		for _, page := range pages {
+			err = writeLine(opts.stdout, page)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return writeJSON(opts.stdout, pages)
+}
+
+func runDegrees(ctx context.Context, opts *options, args []string) error {
+	if len(args) != 1 {
+		return errors.New("degrees: expected a host")
+	}
+	c := opts.client()
+
+	degrees, err := c.CatalogContext(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	if opts.format == "ndjson" {
+		for _, degree := range degrees {
+			err = writeLine(opts.stdout, degree)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if degrees == nil {
+		degrees = []newstojson.Degree{}
+	}
+	return writeJSON(opts.stdout, degrees)
+}
+
+// readFeed reads the feed from a local file or, if arg is an http(s) url,
+// downloads it
+func readFeed(arg string, timeout time.Duration) ([]byte, error) {
+	if !strings.HasPrefix(arg, "http://") && !strings.HasPrefix(arg, "https://") {
+		return ioutil.ReadFile(arg)
+	}
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(arg)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", arg, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// writeNews writes res as a JSON array or, for ndjson, one news per line
+func writeNews(w io.Writer, format string, res []*newstojson.News) error {
+	if format == "ndjson" {
+		for _, item := range res {
+			err := writeLine(w, item)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if res == nil {
+		res = []*newstojson.News{}
+	}
+	return writeJSON(w, res)
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func writeLine(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
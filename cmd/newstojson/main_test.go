@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/giovanni-liboni/newstojson"
+)
+
+// fixtureFetcher answers every request with the recorded pages of
+// ../../testdata/site, named as in the fixtures of the library
+func fixtureFetcher(t *testing.T) newstojson.Fetcher {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		name := q.Get("ent")
+		for _, key := range []string{"id", "cs", "tcs"} {
+			if v := q.Get(key); v != "" {
+				name += "_" + v
+				break
+			}
+		}
+		dir := filepath.FromSlash(path.Clean("/" + r.URL.Path))
+		http.ServeFile(w, r, filepath.Join("../../testdata/site", r.Host, dir, name+".html"))
+	}))
+	t.Cleanup(srv.Close)
+	target, _ := url.Parse(srv.URL)
+
+	return newstojson.FetcherFunc(func(req *http.Request) (*http.Response, error) {
+		r := req.Clone(req.Context())
+		r.URL.Scheme = target.Scheme
+		r.URL.Host = target.Host
+		r.Host = req.URL.Host
+
+		resp, err := srv.Client().Do(r)
+		if err != nil {
+			return nil, err
+		}
+		resp.Request = req
+		return resp, nil
+	})
+}
+
+const testFeed = `<rss version="2.0"><channel>
+	<item>
+		<title>Removed notice</title>
+		<link>http://www.di.univr.it/?ent=avviso&id=999999</link>
+	</item>
+	<item>
+		<title>Nursing internship</title>
+		<link>http://www.medicina.univr.it/fol/?ent=avviso&amp;dest=25&amp;id=119149</link>
+	</item>
+</channel></rss>`
+
+func TestRunFeed(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "feed.rss")
+	err := ioutil.WriteFile(file, []byte(testFeed), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		flags  []string
+		err    string   // expected error, empty if none
+		lines  int      // lines of the output
+		logged []string // expected in the log
+	}{
+		{[]string{}, "", 0, []string{"escaped 1 unescaped &", "field=content"}},
+		{[]string{"-format", "ndjson"}, "", 2, []string{"field=content"}},
+		{[]string{"-strict"}, "404 Not Found", 0, nil},
+		{[]string{"-format", "xml"}, `unknown format "xml"`, 0, nil},
+		{[]string{"-since", "yesterday"}, `invalid -since "yesterday"`, 0, nil},
+		{[]string{"-types", "X"}, `unknown course type "X"`, 0, nil},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		fs := flag.NewFlagSet("newstojson feed", flag.ContinueOnError)
+		opts, err := parseFlags(fs, append(tt.flags, file))
+		if err == nil {
+			opts.fetcher = fixtureFetcher(t)
+			opts.stdout, opts.stderr = &stdout, &stderr
+			err = runFeed(context.Background(), opts, fs.Args())
+		}
+
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%v: unexpected error %v", tt.flags, err)
+			continue
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%v: expected error %q, got %v", tt.flags, tt.err, err)
+			continue
+		}
+		for _, s := range tt.logged {
+			if !strings.Contains(stderr.String(), s) {
+				t.Errorf("%v: expected %q in the log %q", tt.flags, s, stderr.String())
+			}
+		}
+		if err != nil {
+			continue
+		}
+
+		var res []*newstojson.News
+		if tt.lines == 0 {
+			err = json.Unmarshal(stdout.Bytes(), &res)
+		} else {
+			lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+			if len(lines) != tt.lines {
+				t.Errorf("%v: expected %d lines, got %d", tt.flags, tt.lines, len(lines))
+			}
+			for _, line := range lines {
+				var item *newstojson.News
+				err = errors.Join(err, json.Unmarshal([]byte(line), &item))
+				res = append(res, item)
+			}
+		}
+		if err != nil {
+			t.Errorf("%v: invalid output: %v", tt.flags, err)
+			continue
+		}
+		if len(res) != 2 || res[0].Title != "Removed notice" || res[1].Author != "Luisa Saiani" {
+			t.Errorf("%v: unexpected output %s", tt.flags, stdout.String())
+		}
+	}
+}
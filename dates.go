@@ -0,0 +1,106 @@
+package newstojson
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	// The Europe/Rome zone is embedded for the systems without a time zone
+	// database, like scratch containers and Windows
+	_ "time/tzdata"
+)
+
+// ============================================================================
+// Dates of the news details
+// ============================================================================
+
+var (
+	romeOnce sync.Once
+	rome     *time.Location
+)
+
+// romeLocation returns the Europe/Rome time zone of the notice dates, loaded
+// once. The embedded database is used if the system has none.
+func romeLocation() *time.Location {
+	romeOnce.Do(func() {
+		var err error
+		rome, err = time.LoadLocation("Europe/Rome")
+		if err != nil {
+			// Only if the embedded database is broken too
+			rome = time.FixedZone("CET", 3600)
+		}
+	})
+	return rome
+}
+
+// detailsDateLayouts are the layouts of the details dates once the spaces
+// and the weekday are removed and the month names are translated to English
+var detailsDateLayouts = func() []string {
+	dates := []string{"January2,2006", "Jan2,2006", "2January2006", "2Jan2006", "2/1/2006"}
+	clocks := []string{"3:4:5PM", "3:4PM", "15:4:5", "15:4"}
+	var res []string
+	for _, date := range dates {
+		for _, sep := range []string{"-", ",", ""} {
+			for _, clock := range clocks {
+				res = append(res, date+sep+clock)
+			}
+		}
+		res = append(res, date)
+	}
+	return res
+}()
+
+// trimWeekday removes the weekday leading value, full or abbreviated, in
+// English or in the Days of l. It must be done before the month names are
+// translated, since an abbreviated weekday may read as a month, like the
+// Italian "mar" for martedì.
+func (l PageLanguage) trimWeekday(value string) string {
+	var names, abbreviations []string
+	for i := time.Sunday; i <= time.Saturday; i++ {
+		names = append(names, i.String())
+		abbreviations = append(abbreviations, i.String()[:3])
+	}
+	for _, name := range l.Days {
+		names = append(names, regexp.QuoteMeta(name))
+		if short := []rune(name); len(short) > 3 {
+			abbreviations = append(abbreviations, regexp.QuoteMeta(string(short[:3])))
+		}
+	}
+	// The weekday must be followed by a dot, a comma or a space, so that
+	// marzo is not read as mar
+	re := regexp.MustCompile(`(?i)^(?:` + strings.Join(append(names, abbreviations...), "|") + `)[.,\s]+`)
+	return re.ReplaceAllString(value, "")
+}
+
+var errDateLayout = errors.New("date matches no known layout")
+
+// parseDetailsDate parses a date of the news details in the Europe/Rome time
+// zone. Both the English and the Italian pages are understood, like
+// "Monday, October 31, 2016 - 10:26:32 AM" and
+// "lunedì 31 ottobre 2016 - 10:26:32", with or without seconds and weekday
+// and with abbreviated month names. The DateLayout of lang is tried first.
+func parseDetailsDate(text string, lang PageLanguage) (time.Time, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return time.Time{}, errDateLayout
+	}
+	loc := romeLocation()
+
+	if lang.DateLayout != "" {
+		t, err := time.ParseInLocation(lang.DateLayout, lang.englishDate(SpaceMap(text)), loc)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	value := lang.englishDate(SpaceMap(lang.trimWeekday(text)))
+	for _, layout := range detailsDateLayouts {
+		t, err := time.ParseInLocation(layout, value, loc)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errDateLayout
+}
@@ -0,0 +1,59 @@
+package newstojson
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseDetailsDate(t *testing.T) {
+	eng, ita := UnivrProfile.Languages["eng"], UnivrProfile.Languages["ita"]
+	rome := romeLocation()
+	oct31 := time.Date(2016, 10, 31, 10, 26, 32, 0, rome)
+	oct31NoSeconds := time.Date(2016, 10, 31, 10, 26, 0, 0, rome)
+	var tests = []struct {
+		value    string
+		lang     PageLanguage
+		expected time.Time
+	}{
+		{"Monday, October 31, 2016 - 10:26:32 AM", eng, oct31},
+		{"Monday, October 31, 2016 - 10:26 AM", eng, oct31NoSeconds},
+		{"Tuesday, November 1, 2016 - 3:05:00 PM", eng, time.Date(2016, 11, 1, 15, 5, 0, 0, rome)},
+		{"Mon, Oct 31, 2016 - 10:26:32", eng, oct31},
+		{"October 31, 2016 10:26", eng, oct31NoSeconds},
+		{"lunedì 31 ottobre 2016 - 10:26:32", ita, oct31},
+		{"Lunedì 31 Ottobre 2016 - 10:26", ita, oct31NoSeconds},
+		{"31 ott. 2016, 10:26", ita, oct31NoSeconds},
+		{"31/10/2016 10:26", ita, oct31NoSeconds},
+		{"martedì 1 marzo 2016", ita, time.Date(2016, 3, 1, 0, 0, 0, 0, rome)},
+		{"mar 1 mar 2016 - 9:00", ita, time.Date(2016, 3, 1, 9, 0, 0, 0, rome)},
+		{"lun. 31 ott. 2016 - 10:26", ita, oct31NoSeconds},
+		// Summer time
+		{"Friday, July 1, 2016 - 9:00 AM", eng, time.Date(2016, 7, 1, 7, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		res, err := parseDetailsDate(tt.value, tt.lang)
+		if err != nil {
+			t.Errorf("%s: %v", tt.value, err)
+		} else if !res.Equal(tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.value, tt.expected, res)
+		}
+	}
+
+	for _, value := range []string{"", "1st of November", "31 Brumaire 2016"} {
+		if _, err := parseDetailsDate(value, eng); !errors.Is(err, errDateLayout) {
+			t.Errorf("%q: expected errDateLayout, got %v", value, err)
+		}
+	}
+}
+
+func TestParseDetailsDateLayout(t *testing.T) {
+	lang := PageLanguage{DateLayout: "02/01/2006-15h04"}
+	res, err := parseDetailsDate("31/10/2016 - 10h26", lang)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Equal(time.Date(2016, 10, 31, 10, 26, 0, 0, romeLocation())) {
+		t.Error("Unexpected date", res)
+	}
+}
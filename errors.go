@@ -0,0 +1,60 @@
+package newstojson
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+var (
+	// ErrNotFound is returned when a page does not exist on the site
+	ErrNotFound = errors.New("newstojson: page not found")
+
+	// ErrLayoutChanged is returned when a page lacks the elements the parser
+	// looks for, usually because the site was redesigned
+	ErrLayoutChanged = errors.New("newstojson: page layout changed")
+
+	// ErrInvalidNewsURL is returned for a url that does not point to a news
+	ErrInvalidNewsURL = errors.New("newstojson: invalid news url")
+)
+
+// StatusError is returned when the site answers with a status other than
+// 200 OK. Errors for 404 and 410 match ErrNotFound.
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("newstojson: %s: %d %s", e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Unwrap returns ErrNotFound for a missing page
+func (e *StatusError) Unwrap() error {
+	if e.StatusCode == http.StatusNotFound || e.StatusCode == http.StatusGone {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// FieldError reports a value of the page that could not be parsed
+type FieldError struct {
+	Field string // JSON name of the field, like pub_time
+	Raw   string // Text found in the page
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return "newstojson: " + e.Field + " " + strconv.Quote(e.Raw) + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// layoutChanged returns an ErrLayoutChanged error for the missing selector
+// of the page at url
+func layoutChanged(url, selector string) error {
+	return fmt.Errorf("%w: %s: no %s", ErrLayoutChanged, url, selector)
+}
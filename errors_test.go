@@ -0,0 +1,91 @@
+package newstojson
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestErrNotFound(t *testing.T) {
+	c := newFixtureClient(t)
+
+	link, _ := url.Parse("http://www.di.univr.it/?ent=avviso&id=1")
+	_, err := c.ParseFromLink(link)
+	if !errors.Is(err, ErrNotFound) {
+		t.Error("Expected ErrNotFound, got", err)
+	}
+	var serr *StatusError
+	if !errors.As(err, &serr) || serr.StatusCode != 404 {
+		t.Error("Expected a 404 StatusError, got", err)
+	}
+
+	_, err = c.RetriveLast5NewsIDsFromNewsPageContext(context.Background(), "www.di.univr.it/?ent=avvisoin&cs=1")
+	if !errors.Is(err, ErrNotFound) {
+		t.Error("Expected ErrNotFound, got", err)
+	}
+}
+
+func TestErrLayoutChanged(t *testing.T) {
+	link, _ := url.Parse("http://www.di.univr.it/?ent=avviso&id=119016")
+
+	var tests = []string{
+		`<html><body><h1>Exam results</h1><div class="main-text">Text</div></body></html>`,
+		`<html><body><div class="main-text">Text</div><dl id="dettagliAvviso"></dl></body></html>`,
+		`<html><body><h1>Exam results</h1><dl id="dettagliAvviso"></dl></body></html>`,
+	}
+	for _, page := range tests {
+		_, err := ParseHTML(strings.NewReader(page), link)
+		if !errors.Is(err, ErrLayoutChanged) {
+			t.Errorf("%s: expected ErrLayoutChanged, got %v", page, err)
+		}
+	}
+
+	c := newFixtureClient(t)
+	// A news page is not a course list
+	_, err := c.NewsPageLinksFromURLCorsoMedicina("www.di.univr.it/?ent=avviso&id=119016")
+	if !errors.Is(err, ErrLayoutChanged) {
+		t.Error("Expected ErrLayoutChanged, got", err)
+	}
+}
+
+func TestFieldError(t *testing.T) {
+	link, _ := url.Parse("http://www.di.univr.it/?ent=avviso&id=119016")
+	page := strings.Replace(testNewsPage, "Tuesday, November 1, 2016 - 3:05:00 PM", "1st of November", 1)
+
+	item, err := ParseHTML(strings.NewReader(page), link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(item.Warnings) != 1 || item.Warnings[0].Field != "mod_time" || item.Warnings[0].Raw != "1st of November" {
+		t.Error("Expected a mod_time warning, got", item.Warnings)
+	}
+	if !item.ModTime.IsZero() || item.PubTime.IsZero() {
+		t.Error("Expected only the pub time, got", item.PubTime, item.ModTime)
+	}
+
+	// Strict mode turns the warning into an error
+	c := &Client{SkipPreviews: true, Strict: true}
+	_, err = c.ParseHTMLContext(context.Background(), strings.NewReader(page), link)
+	var ferr *FieldError
+	if !errors.As(err, &ferr) {
+		t.Fatal("Expected a FieldError, got", err)
+	}
+	if ferr.Field != "mod_time" || ferr.Raw != "1st of November" {
+		t.Error("Unexpected field error", ferr)
+	}
+}
+
+func TestErrInvalidNewsURL(t *testing.T) {
+	var item News
+	err := item.SetIDFromURL("www.univr.it/?ent=cs&id=100")
+	if !errors.Is(err, ErrInvalidNewsURL) {
+		t.Error("Expected ErrInvalidNewsURL, got", err)
+	}
+
+	_, err = ParseHTML(strings.NewReader(testNewsPage), nil)
+	if !errors.Is(err, ErrInvalidNewsURL) {
+		t.Error("Expected ErrInvalidNewsURL for a nil link, got", err)
+	}
+}
@@ -0,0 +1,505 @@
+package newstojson
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Feed is an RSS 2.0 or Atom feed of news
+type Feed struct {
+	Title       string
+	Link        string
+	Description string
+	Items       []*News
+	Warnings    []FeedWarning // Defects found and recovered while reading
+}
+
+// FeedWarning describes a defect of the feed that did not stop the reading
+type FeedWarning struct {
+	Item    int // Position of the item in the feed starting from 1, 0 for the whole feed
+	Message string
+}
+
+func (w FeedWarning) String() string {
+	if w.Item == 0 {
+		return w.Message
+	}
+	return fmt.Sprintf("item %d: %s", w.Item, w.Message)
+}
+
+type rssItem struct {
+	Title       string
+	Link        string
+	Description string
+	PubDate     string
+	GUID        string
+}
+
+// atomFeed is the document element of an Atom feed
+type atomFeed struct {
+	Title   string      `xml:"title"`
+	Entries []atomEntry `xml:"entry"`
+	Links   []atomLink  `xml:"link"`
+}
+
+type atomEntry struct {
+	Title     string     `xml:"title"`
+	ID        string     `xml:"id"`
+	Summary   string     `xml:"summary"`
+	Content   string     `xml:"content"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Links     []atomLink `xml:"link"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+// alternate returns the href of the first alternate link
+func alternate(links []atomLink) string {
+	for _, l := range links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+// pubDateLayouts are the date formats accepted in the RSS pubDate element
+var pubDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+}
+
+// ParseFeed reads an RSS 2.0 or Atom feed from r and returns its items
+// without touching the network: only the title, link, description, dates and
+// ID found in the feed are set. Malformed items are recovered or skipped as
+// described in ReadFeed.
+func ParseFeed(r io.Reader) ([]*News, error) {
+	feed, err := ReadFeed(r)
+	if err != nil {
+		return nil, err
+	}
+	return feed.Items, nil
+}
+
+// ReadFeed is like ParseFeed but returns the whole feed. The known defects of
+// the department feeds are repaired: unescaped & characters, end tags written
+// as /link> and item elements missing their <item> start tag. An item whose
+// link has no ID takes it from the guid. Items that cannot be recovered are
+// skipped; every repair and skipped item is reported in Feed.Warnings.
+func ReadFeed(r io.Reader) (*Feed, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	feed := new(Feed)
+	data = repairFeed(data, feed)
+
+	root, err := rootElement(data)
+	if err != nil {
+		return nil, err
+	}
+	switch root {
+	case "rss":
+		err = readRSS(data, feed)
+	case "feed":
+		err = readAtom(data, feed)
+	default:
+		err = errors.New("newstojson: unknown feed format <" + root + ">")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return feed, nil
+}
+
+// ParseFeed is like the package level ParseFeed but also retrives the
+// content of every item from its page using c
+func (c *Client) ParseFeed(r io.Reader) ([]*News, error) {
+	return c.ParseFeedContext(context.Background(), r)
+}
+
+// ParseFeedContext is like ParseFeed but stops as soon as ctx is done. The
+// defects repaired in the feed are logged as warnings. An item whose page
+// cannot be parsed keeps the fields of the feed and gets a content warning;
+// in strict mode the items parsed so far are returned with the error.
+func (c *Client) ParseFeedContext(ctx context.Context, r io.Reader) ([]*News, error) {
+	feed, err := ReadFeed(r)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range feed.Warnings {
+		c.log(ctx, slog.LevelWarn, w.Message, "item", w.Item)
+	}
+
+	res := feed.Items
+	for i, news := range res {
+		from := len(news.Warnings)
+		err = c.GetContentFromURLContext(ctx, news)
+		if ctx.Err() != nil {
+			return res[:i], ctx.Err()
+		}
+		if err == nil {
+			continue
+		}
+		if c.Strict {
+			return res[:i], err
+		}
+		news.warnErr("content", "page not parsed", err, news.Link.String())
+		c.report(ctx, news, from)
+	}
+	return res, nil
+}
+
+// =============================================================================
+// Feed repair functions
+// =============================================================================
+
+var (
+	// entity matches what may follow an & to form a valid reference
+	entity = regexp.MustCompile(`^(#[0-9]+|#x[0-9a-fA-F]+|[a-zA-Z][a-zA-Z0-9]*);`)
+	// brokenEndTag matches end tags that lost their <, like lang=eng/link>
+	brokenEndTag = regexp.MustCompile(`([^<\s])/(title|link|description|pubDate|guid|item)>`)
+)
+
+// verbatim are the sections of XML whose & characters are not references,
+// as start and end delimiters
+var verbatim = [][2]string{{"<![CDATA[", "]]>"}, {"<!--", "-->"}}
+
+// skipVerbatim returns the length of the CDATA section or comment at the start
+// of data, 0 if there is none
+func skipVerbatim(data []byte) int {
+	for _, v := range verbatim {
+		if !bytes.HasPrefix(data, []byte(v[0])) {
+			continue
+		}
+		end := bytes.Index(data[len(v[0]):], []byte(v[1]))
+		if end < 0 {
+			return len(data)
+		}
+		return len(v[0]) + end + len(v[1])
+	}
+	return 0
+}
+
+// repairFeed fixes the defects of data that make it invalid XML, reporting
+// them in feed. CDATA sections and comments are left as they are.
+func repairFeed(data []byte, feed *Feed) []byte {
+	var buf bytes.Buffer
+	escaped := 0
+	for i := 0; i < len(data); i++ {
+		if n := skipVerbatim(data[i:]); n > 0 {
+			buf.Write(data[i : i+n])
+			i += n - 1
+			continue
+		}
+		if data[i] == '&' && !entity.Match(data[i+1:]) {
+			buf.WriteString("&amp;")
+			escaped++
+			continue
+		}
+		buf.WriteByte(data[i])
+	}
+	if escaped > 0 {
+		feed.warn(0, fmt.Sprintf("escaped %d unescaped & characters", escaped))
+	}
+
+	res := buf.Bytes()
+	if n := len(brokenEndTag.FindAll(res, -1)); n > 0 {
+		res = brokenEndTag.ReplaceAll(res, []byte("$1</$2>"))
+		feed.warn(0, fmt.Sprintf("repaired %d end tags missing the <", n))
+	}
+	return res
+}
+
+// rootElement returns the name of the document element of data
+func rootElement(data []byte) (string, error) {
+	d := newFeedDecoder(data)
+	for {
+		tok, err := d.RawToken()
+		if err != nil {
+			return "", err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+func newFeedDecoder(data []byte) *xml.Decoder {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = false
+	d.Entity = xml.HTMLEntity
+	return d
+}
+
+func (feed *Feed) warn(item int, message string) {
+	feed.Warnings = append(feed.Warnings, FeedWarning{Item: item, Message: message})
+}
+
+// =============================================================================
+// Feed read functions
+// =============================================================================
+
+// readRSS reads the channel and the items of an RSS 2.0 document. The tokens
+// are read raw, without matching start and end elements, so that item fields
+// found outside an <item> are collected as a new item instead of failing.
+func readRSS(data []byte, feed *Feed) error {
+	d := newFeedDecoder(data)
+
+	var (
+		item     *rssItem // item being read
+		orphan   bool     // item has no <item> start tag
+		position int      // position of item in the feed
+		field    string   // item or channel field being read
+		text     strings.Builder
+	)
+	flush := func() {
+		if item == nil {
+			return
+		}
+		if orphan {
+			feed.warn(position, "missing <item> start tag")
+		}
+		news := newsFromRSS(*item, position, feed)
+		if news != nil {
+			feed.Items = append(feed.Items, news)
+		}
+		item, orphan = nil, false
+	}
+
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			// Elements of other namespaces, like atom:link, are not RSS fields
+			if t.Name.Space != "" {
+				field = ""
+				continue
+			}
+			switch t.Name.Local {
+			case "item":
+				flush()
+				position++
+				item = new(rssItem)
+			case "title", "link", "description", "pubDate", "guid":
+				field = t.Name.Local
+				text.Reset()
+			default:
+				field = ""
+			}
+		case xml.CharData:
+			if field != "" {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			switch {
+			case t.Name.Space == "" && t.Name.Local == field:
+				value := strings.TrimSpace(text.String())
+				if item == nil && position > 0 {
+					// A field after the first item but outside any item
+					position++
+					item, orphan = new(rssItem), true
+				}
+				if item != nil {
+					item.set(field, value)
+				} else {
+					feed.set(field, value)
+				}
+				field = ""
+			case t.Name.Local == "item", t.Name.Local == "channel":
+				flush()
+			}
+		}
+	}
+	flush()
+
+	return nil
+}
+
+func (item *rssItem) set(field, value string) {
+	switch field {
+	case "title":
+		item.Title = value
+	case "link":
+		item.Link = value
+	case "description":
+		item.Description = value
+	case "pubDate":
+		item.PubDate = value
+	case "guid":
+		item.GUID = value
+	}
+}
+
+func (feed *Feed) set(field, value string) {
+	switch field {
+	case "title":
+		feed.Title = value
+	case "link":
+		feed.Link = value
+	case "description":
+		feed.Description = value
+	}
+}
+
+// readAtom reads the entries of an Atom document
+func readAtom(data []byte, feed *Feed) error {
+	var doc atomFeed
+	err := newFeedDecoder(data).Decode(&doc)
+	if err != nil {
+		return err
+	}
+
+	feed.Title = strings.TrimSpace(doc.Title)
+	feed.Link = alternate(doc.Links)
+	for i, entry := range doc.Entries {
+		news := newsFromAtom(entry, i+1, feed)
+		if news != nil {
+			feed.Items = append(feed.Items, news)
+		}
+	}
+	return nil
+}
+
+// newsFromRSS builds the news of the item at position of feed. Recoverable
+// problems are reported as warnings of feed; nil is returned if the item has
+// neither a link nor a guid.
+func newsFromRSS(item rssItem, position int, feed *Feed) *News {
+	var err error
+	news := new(News)
+
+	news.Title = item.Title
+	news.Description = item.Description
+
+	if item.PubDate != "" {
+		news.PubTime, err = parseTime(pubDateLayouts, item.PubDate)
+		if err != nil {
+			feed.warn(position, "invalid pubDate "+strconv.Quote(item.PubDate))
+		}
+	}
+
+	warnings, ok := news.setLinkAndID(item.Link, item.GUID)
+	for _, w := range warnings {
+		feed.warn(position, w.Message)
+	}
+	if !ok {
+		return nil
+	}
+	return news
+}
+
+// newsFromAtom is like newsFromRSS for an Atom entry
+func newsFromAtom(entry atomEntry, position int, feed *Feed) *News {
+	var err error
+	news := new(News)
+
+	news.Title = strings.TrimSpace(entry.Title)
+	news.Description = strings.TrimSpace(entry.Summary)
+	if news.Description == "" {
+		news.Description = strings.TrimSpace(entry.Content)
+	}
+
+	if date := strings.TrimSpace(entry.Published); date != "" {
+		news.PubTime, err = time.Parse(time.RFC3339, date)
+		if err != nil {
+			feed.warn(position, "invalid published "+strconv.Quote(date))
+		}
+	}
+	if date := strings.TrimSpace(entry.Updated); date != "" {
+		news.ModTime, err = time.Parse(time.RFC3339, date)
+		if err != nil {
+			feed.warn(position, "invalid updated "+strconv.Quote(date))
+		}
+		if news.PubTime.IsZero() {
+			news.PubTime = news.ModTime
+		}
+	}
+
+	warnings, ok := news.setLinkAndID(alternate(entry.Links), entry.ID)
+	for _, w := range warnings {
+		feed.warn(position, w.Message)
+	}
+	if !ok {
+		return nil
+	}
+	return news
+}
+
+// setLinkAndID sets the link of the news, or the guid if the link is missing
+// or invalid, and takes the ID from its id parameter, or from the one of the
+// guid if the link has none. It returns what had to be recovered, as link
+// and id warnings, and false if the news has no link.
+func (item *News) setLinkAndID(link, guid string) (warnings []Warning, ok bool) {
+	var err error
+	link, guid = strings.TrimSpace(link), strings.TrimSpace(guid)
+
+	if link != "" {
+		item.Link, err = url.Parse(link)
+		if err != nil {
+			warnings = append(warnings, Warning{Field: "link", Message: "invalid link " + strconv.Quote(link), Raw: link, err: err})
+		}
+	}
+	if item.Link == nil && guid != "" {
+		item.Link, err = url.Parse(guid)
+		if err != nil || !item.Link.IsAbs() {
+			item.Link = nil
+		} else {
+			warnings = append(warnings, Warning{Field: "link", Message: "link taken from guid", Raw: guid})
+		}
+	}
+	if item.Link == nil {
+		return append(warnings, Warning{Field: "link", Message: "skipped, no link", Raw: guid}), false
+	}
+
+	item.ID, _ = strconv.Atoi(item.Link.Query().Get("id"))
+	if item.ID == 0 {
+		if u, err := url.Parse(guid); err == nil {
+			item.ID, _ = strconv.Atoi(u.Query().Get("id"))
+		}
+		if item.ID != 0 {
+			warnings = append(warnings, Warning{Field: "id", Message: "ID taken from guid", Raw: guid})
+		} else {
+			warnings = append(warnings, Warning{Field: "id", Message: "no ID in link and guid", Raw: item.Link.String()})
+		}
+	}
+	return warnings, true
+}
+
+// parseTime parses value with the first of layouts that matches
+func parseTime(layouts []string, value string) (time.Time, error) {
+	var err error
+	for _, layout := range layouts {
+		var t time.Time
+		t, err = time.Parse(layout, value)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
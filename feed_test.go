@@ -0,0 +1,287 @@
+package newstojson
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFeed(t *testing.T) {
+	var tests = []struct {
+		file  string
+		ids   []int
+		links []string
+		pub   []time.Time
+		mod   []time.Time
+	}{
+		{
+			"testdata/feed.rss",
+			[]int{119016, 123492, 130134},
+			[]string{
+				"http://www.di.univr.it/?ent=avviso&dest=&id=119016&lang=eng",
+				"http://www.di.univr.it/?dest=&ent=avviso&id=123492&lang=eng",
+				"http://www.di.univr.it/?ent=avviso&dest=165&lang=eng",
+			},
+			[]time.Time{
+				time.Date(2016, 10, 31, 9, 26, 32, 0, time.UTC),
+				time.Date(2017, 9, 17, 7, 11, 13, 0, time.UTC),
+				time.Date(2017, 11, 20, 10, 3, 27, 0, time.UTC),
+			},
+			[]time.Time{{}, {}, {}},
+		},
+		{
+			"testdata/feed.atom",
+			[]int{119016, 130134},
+			[]string{
+				"http://www.di.univr.it/?ent=avviso&dest=&id=119016&lang=eng",
+				"http://www.di.univr.it/?ent=avviso&dest=165&lang=eng",
+			},
+			[]time.Time{
+				time.Date(2016, 10, 31, 9, 26, 32, 0, time.UTC),
+				time.Date(2017, 11, 20, 10, 3, 27, 0, time.UTC),
+			},
+			[]time.Time{
+				time.Date(2016, 11, 2, 8, 12, 5, 0, time.UTC),
+				time.Date(2017, 11, 20, 10, 3, 27, 0, time.UTC),
+			},
+		},
+	}
+	for _, tt := range tests {
+		f, err := os.Open(tt.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := ParseFeed(f)
+		f.Close()
+		if err != nil {
+			t.Errorf("%s: %v", tt.file, err)
+			continue
+		}
+		if len(res) != len(tt.ids) {
+			t.Errorf("%s: expected %d items, got %d", tt.file, len(tt.ids), len(res))
+			continue
+		}
+		for i, item := range res {
+			if item.ID != tt.ids[i] {
+				t.Errorf("%s[%d]: expected ID %d, got %d", tt.file, i, tt.ids[i], item.ID)
+			}
+			if item.Link.String() != tt.links[i] {
+				t.Errorf("%s[%d]: expected link %s, got %s", tt.file, i, tt.links[i], item.Link)
+			}
+			if !item.PubTime.Equal(tt.pub[i]) {
+				t.Errorf("%s[%d]: expected pub time %v, got %v", tt.file, i, tt.pub[i], item.PubTime)
+			}
+			if !item.ModTime.Equal(tt.mod[i]) {
+				t.Errorf("%s[%d]: expected mod time %v, got %v", tt.file, i, tt.mod[i], item.ModTime)
+			}
+			if item.Title == "" || item.Description == "" {
+				t.Errorf("%s[%d]: expected title and description, got %q %q", tt.file, i, item.Title, item.Description)
+			}
+		}
+	}
+
+	_, err := ParseFeed(strings.NewReader(`<html><body></body></html>`))
+	if err == nil {
+		t.Error("Expected error but got nil")
+	}
+}
+
+func TestClientParseFeed(t *testing.T) {
+	c := newFixtureClient(t)
+	feed := `<rss version="2.0"><channel>
+		<item>
+			<title>Exam results</title>
+			<link>http://www.di.univr.it/?ent=avviso&amp;id=119016</link>
+			<pubDate>Mon, 31 Oct 2016 10:26:32 +0100</pubDate>
+		</item>
+		<item>
+			<title>Nursing internship</title>
+			<link>http://www.medicina.univr.it/fol/?ent=avviso&amp;dest=25&amp;id=119149</link>
+			<pubDate>Mon, 24 Oct 2016 14:17:50 +0200</pubDate>
+		</item>
+	</channel></rss>`
+
+	res, err := c.ParseFeed(strings.NewReader(feed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 {
+		t.Fatal("Expected 2 items, got", len(res))
+	}
+	if res[0].Author != "Roberto Segala" || res[1].Author != "Luisa Saiani" {
+		t.Error("Expected the authors from the pages, got", res[0].Author, res[1].Author)
+	}
+	if len(res[1].Attachments) != 1 {
+		t.Error("Expected 1 attachment, got", len(res[1].Attachments))
+	}
+}
+
+func TestClientParseFeedMissingPage(t *testing.T) {
+	c := newFixtureClient(t)
+	fixtures := c.Fetcher
+	c.Fetcher = FetcherFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Query().Get("id") == "999999" {
+			return nil, errors.New("connection reset")
+		}
+		return fixtures.Do(req)
+	})
+	feed := `<rss version="2.0"><channel>
+		<item>
+			<title>Removed notice</title>
+			<link>http://www.di.univr.it/?ent=avviso&amp;id=999999</link>
+			<pubDate>Mon, 31 Oct 2016 10:26:32 +0100</pubDate>
+		</item>
+		<item>
+			<title>Exam results</title>
+			<link>http://www.di.univr.it/?ent=avviso&amp;id=119016</link>
+			<pubDate>Mon, 31 Oct 2016 10:26:32 +0100</pubDate>
+		</item>
+	</channel></rss>`
+
+	res, err := c.ParseFeed(strings.NewReader(feed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 {
+		t.Fatal("Expected 2 items, got", len(res))
+	}
+	if res[0].Title != "Removed notice" || len(res[0].Warnings) != 1 || res[0].Warnings[0].Field != "content" {
+		t.Error("Expected the feed fields and a content warning, got", res[0].Title, res[0].Warnings)
+	}
+	if res[1].Author != "Roberto Segala" {
+		t.Error("Expected the author from the page, got", res[1].Author)
+	}
+
+	c.Strict = true
+	res, err = c.ParseFeed(strings.NewReader(feed))
+	var fieldErr *FieldError
+	if err == nil || errors.As(err, &fieldErr) || len(res) != 0 {
+		t.Error("Expected the fetch error and no items in strict mode, got", err, res)
+	}
+
+	c.Fetcher = fixtures
+	res, err = c.ParseFeed(strings.NewReader(feed))
+	if !errors.Is(err, ErrNotFound) || len(res) != 0 {
+		t.Error("Expected ErrNotFound and no items in strict mode, got", err, res)
+	}
+}
+
+func TestReadFeedMalformed(t *testing.T) {
+	f, err := os.Open("testdata/data.rss")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	feed, err := ReadFeed(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.Title != "Avvisi per studenti - Dipartimento Informatica" {
+		t.Error("Unexpected feed title", feed.Title)
+	}
+
+	ids := []int{119084, 123492, 118037}
+	links := []string{
+		"http://www.di.univr.it/?ent=avviso&dest=&id=119084&lang=eng",
+		"http://www.di.univr.it/?dest=&ent=avviso&id=123492&lang=eng",
+		"http://www.di.univr.it/?ent=avviso&dest=165&rss=0&id=118037&lang=eng",
+	}
+	if len(feed.Items) != len(ids) {
+		t.Fatal("Expected", len(ids), "items, got", len(feed.Items))
+	}
+	for i, item := range feed.Items {
+		if item.ID != ids[i] {
+			t.Errorf("item %d: expected ID %d, got %d", i+1, ids[i], item.ID)
+		}
+		if item.Link.String() != links[i] {
+			t.Errorf("item %d: expected link %s, got %s", i+1, links[i], item.Link)
+		}
+		if item.PubTime.IsZero() || item.Description == "" {
+			t.Errorf("item %d: missing pub time or description", i+1)
+		}
+	}
+	if feed.Items[2].Title != "Esito prova scritta GENETICA del 09/09/2016" {
+		t.Error("Unexpected title of the item without <item>", feed.Items[2].Title)
+	}
+
+	expected := []FeedWarning{
+		{0, "escaped 2 unescaped & characters"},
+		{0, "repaired 1 end tags missing the <"},
+		{3, "missing <item> start tag"},
+	}
+	if !reflect.DeepEqual(feed.Warnings, expected) {
+		t.Error("Expected warnings", expected, "got", feed.Warnings)
+	}
+}
+
+func TestReadFeedCDATA(t *testing.T) {
+	feed, err := ReadFeed(strings.NewReader(`<rss version="2.0"><channel>
+		<!-- Tom & Jerry -->
+		<item>
+			<title><![CDATA[Tom & Jerry]]></title>
+			<link>http://www.di.univr.it/?ent=avviso&id=119016</link>
+			<description><![CDATA[<p>Rock &amp; roll & more</p>]]></description>
+		</item>
+	</channel></rss>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(feed.Items) != 1 {
+		t.Fatal("Expected 1 item, got", len(feed.Items))
+	}
+	item := feed.Items[0]
+	if item.Title != "Tom & Jerry" || item.Description != "<p>Rock &amp; roll & more</p>" {
+		t.Errorf("Expected the CDATA text untouched, got %q %q", item.Title, item.Description)
+	}
+	expected := []FeedWarning{{0, "escaped 1 unescaped & characters"}}
+	if !reflect.DeepEqual(feed.Warnings, expected) {
+		t.Error("Expected warnings", expected, "got", feed.Warnings)
+	}
+}
+
+func TestReadFeedSkipsBadItems(t *testing.T) {
+	feed, err := ReadFeed(strings.NewReader(`<rss version="2.0"><channel>
+		<item>
+			<title>No link</title>
+			<guid>http://www.di.univr.it/?ent=avviso&amp;id=119016</guid>
+			<pubDate>yesterday</pubDate>
+		</item>
+		<item>
+			<title>Nothing</title>
+		</item>
+		<item>
+			<title>ID in guid</title>
+			<link>http://www.di.univr.it/?ent=avviso</link>
+			<guid isPermaLink="false">http://www.di.univr.it/?ent=avviso&amp;id=123492</guid>
+		</item>
+	</channel></rss>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(feed.Items) != 2 {
+		t.Fatal("Expected 2 items, got", len(feed.Items))
+	}
+	if feed.Items[0].ID != 119016 || feed.Items[0].Link.String() != "http://www.di.univr.it/?ent=avviso&id=119016" {
+		t.Error("Expected the link from the guid, got", feed.Items[0].Link)
+	}
+	if feed.Items[1].ID != 123492 {
+		t.Error("Expected the ID from the guid, got", feed.Items[1].ID)
+	}
+
+	expected := []FeedWarning{
+		{1, `invalid pubDate "yesterday"`},
+		{1, "link taken from guid"},
+		{2, "skipped, no link"},
+		{3, "ID taken from guid"},
+	}
+	if !reflect.DeepEqual(feed.Warnings, expected) {
+		t.Error("Expected warnings", expected, "got", feed.Warnings)
+	}
+}
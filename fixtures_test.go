@@ -0,0 +1,72 @@
+package newstojson
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"path/filepath"
+	"testing"
+)
+
+// The recorded pages live under testdata/site/<host>/<path>/ and are named
+// after the query of the request that returned them:
+//
+//	/?ent=avviso&id=119016 -> avviso_119016.html
+//	/?ent=avvisoin&cs=417  -> avvisoin_417.html
+//	/?ent=cs&tcs=N         -> cs_N.html
+//	/?ent=avvisoin&cs=420&page=2 -> avvisoin_420_p2.html
+//	/?ent=avviso&id=119016&lang=ita -> avviso_119016_ita.html
+const fixtureRoot = "testdata/site"
+
+// fixturePath returns the file that answers the request for u on host
+func fixturePath(host string, u *url.URL) string {
+	q := u.Query()
+	name := q.Get("ent")
+	for _, key := range []string{"id", "cs", "tcs"} {
+		if v := q.Get(key); v != "" {
+			name += "_" + v
+			break
+		}
+	}
+	if page := q.Get("page"); page != "" {
+		name += "_p" + page
+	}
+	if q.Get("lang") == "ita" {
+		name += "_ita"
+	}
+	dir := path.Clean("/" + u.Path)
+	return filepath.Join(fixtureRoot, host, filepath.FromSlash(dir), name+".html")
+}
+
+// newFixtureServer starts a server that plays the recorded pages back. The
+// page is chosen by the Host header, so a single server stands in for every
+// department site.
+func newFixtureServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, fixturePath(r.Host, r.URL))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newFixtureClient returns a Client whose requests are all answered by a
+// fixture server, whatever host they are addressed to
+func newFixtureClient(t *testing.T) *Client {
+	srv := newFixtureServer(t)
+	target, _ := url.Parse(srv.URL)
+
+	return NewClient(FetcherFunc(func(req *http.Request) (*http.Response, error) {
+		r := req.Clone(req.Context())
+		r.URL.Scheme = target.Scheme
+		r.URL.Host = target.Host
+		r.Host = req.URL.Host
+
+		resp, err := srv.Client().Do(r)
+		if err != nil {
+			return nil, err
+		}
+		resp.Request = req
+		return resp, nil
+	}))
+}
@@ -0,0 +1,10 @@
+module github.com/giovanni-liboni/newstojson
+
+go 1.21
+
+require (
+	github.com/PuerkitoBio/goquery v1.9.2
+	golang.org/x/net v0.24.0
+)
+
+require github.com/andybalholm/cascadia v1.3.2 // indirect
@@ -0,0 +1,254 @@
+package newstojson
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// degreePage is the news page of a degree with the IDs of the news it lists
+type degreePage struct {
+	URL      string
+	Degree   int
+	IDs      []int
+	warnings []Warning
+}
+
+// degreePages crawls the news pages of the degrees of host. The warnings
+// returned are those of the course lists, each page keeps its own.
+func (c *Client) degreePages(ctx context.Context, host string) ([]degreePage, []Warning, error) {
+	var warnings []Warning
+	links, err := c.newsPages(ctx, host, &warnings)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pages := make([]degreePage, len(links))
+	for i, link := range links {
+		pages[i].URL = link
+		pages[i].Degree, _ = getIDFromCompleteURL(link)
+	}
+	pages, err = c.fetchDegreePages(ctx, pages)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pages, warnings, nil
+}
+
+// fetchDegreePages returns a copy of pages with the news IDs downloaded again
+func (c *Client) fetchDegreePages(ctx context.Context, pages []degreePage) ([]degreePage, error) {
+	res := make([]degreePage, len(pages))
+	err := c.forEach(ctx, len(pages), func(ctx context.Context, i int) error {
+		//Recupero gli avvisi da ogni corso
+		res[i] = degreePage{URL: pages[i].URL, Degree: pages[i].Degree}
+		var err error
+		if c.History {
+			res[i].IDs, err = c.retriveAllNewsIDs(ctx, pages[i].URL, &res[i].warnings)
+		} else {
+			res[i].IDs, err = c.retriveLast5NewsIDs(ctx, pages[i].URL, &res[i].warnings)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func pageWarnings(pages []degreePage) []Warning {
+	var res []Warning
+	for _, page := range pages {
+		res = append(res, page.warnings...)
+	}
+	return res
+}
+
+// degreesOf returns the degrees whose page lists the news id, in page order
+func degreesOf(pages []degreePage, id int) []int {
+	var res []int
+	for _, page := range pages {
+		if contains(page.IDs, id) {
+			res = append(res, page.Degree)
+		}
+	}
+	return res
+}
+
+// =============================================================================
+// Degree index
+// =============================================================================
+
+// DegreeIndex keeps the news pages of the degrees of every host with the IDs
+// of the news they list, so that SetIDsCourses crawls a host once instead of
+// once per news. It is safe for concurrent use, also by clients with
+// different settings: a host is crawled once for every combination of
+// History, HistoryPages, HistorySince, CourseTypes and site profile.
+type DegreeIndex struct {
+	// TTL is how long the crawl of a host is reused before being done again
+	// from scratch. Zero means forever.
+	TTL time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]map[string]*hostIndex // By host and crawl settings
+}
+
+// hostIndex is the crawl of a single host
+type hostIndex struct {
+	mu        sync.Mutex
+	built     time.Time // time of the crawl of the course lists
+	refreshed time.Time // time of the last download of the news pages
+	pages     []degreePage
+}
+
+// NewDegreeIndex returns an empty index whose hosts expire after ttl
+func NewDegreeIndex(ttl time.Duration) *DegreeIndex {
+	return &DegreeIndex{TTL: ttl}
+}
+
+// host returns the crawl of host made with the settings of c
+func (idx *DegreeIndex) host(c *Client, host string) *hostIndex {
+	settings := c.crawlSettings(host)
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.hosts == nil {
+		idx.hosts = make(map[string]map[string]*hostIndex)
+	}
+	if idx.hosts[host] == nil {
+		idx.hosts[host] = make(map[string]*hostIndex)
+	}
+	h, ok := idx.hosts[host][settings]
+	if !ok {
+		h = new(hostIndex)
+		idx.hosts[host][settings] = h
+	}
+	return h
+}
+
+// crawlSettings describes the settings of c that change what a crawl of host
+// collects
+func (c *Client) crawlSettings(host string) string {
+	settings := fmt.Sprintf("types=%v profile=%+v", c.courseTypes(), c.ProfileFor(host))
+	if c.History {
+		settings += fmt.Sprintf(" history pages=%d since=%s", c.HistoryPages, c.HistorySince.Format(time.RFC3339Nano))
+	}
+	return settings
+}
+
+// Degrees returns the degrees of host whose news page listed the news id at
+// the last refresh, in any of the crawls of host. It makes no request.
+func (idx *DegreeIndex) Degrees(host string, id int) []int {
+	idx.mu.Lock()
+	var settings []string
+	for s := range idx.hosts[host] {
+		settings = append(settings, s)
+	}
+	sort.Strings(settings)
+	crawls := make([]*hostIndex, len(settings))
+	for i, s := range settings {
+		crawls[i] = idx.hosts[host][s]
+	}
+	idx.mu.Unlock()
+
+	var res []int
+	for _, h := range crawls {
+		h.mu.Lock()
+		for _, degree := range degreesOf(h.pages, id) {
+			if !contains(res, degree) {
+				res = append(res, degree)
+			}
+		}
+		h.mu.Unlock()
+	}
+	return res
+}
+
+// expired reports whether h must be crawled again. h.mu must be held.
+func (idx *DegreeIndex) expired(h *hostIndex) bool {
+	return h.built.IsZero() || idx.TTL > 0 && time.Since(h.built) > idx.TTL
+}
+
+// buildHostIndex crawls host from scratch. h.mu must be held.
+func (c *Client) buildHostIndex(ctx context.Context, h *hostIndex, host string) error {
+	pages, warnings, err := c.degreePages(ctx, host)
+	if err != nil {
+		return err
+	}
+
+	h.built = time.Now()
+	h.refreshed = h.built
+	h.pages = pages
+	c.logCrawl(ctx, host, append(warnings, pageWarnings(pages)...))
+	return nil
+}
+
+// refreshHostIndex downloads again the news pages of h, keeping the course
+// lists. h.mu must be held.
+func (c *Client) refreshHostIndex(ctx context.Context, h *hostIndex, host string) error {
+	start := time.Now()
+	pages, err := c.fetchDegreePages(ctx, h.pages)
+	if err != nil {
+		return err
+	}
+	h.refreshed = start
+	h.pages = pages
+	c.logCrawl(ctx, host, pageWarnings(pages))
+	return nil
+}
+
+// logCrawl logs the warnings of a crawl of host made for the index. They are
+// logged once, when the crawl is made, instead of being added to every news
+// completed with it.
+func (c *Client) logCrawl(ctx context.Context, host string, warnings []Warning) {
+	for _, w := range warnings {
+		args := []interface{}{"host", host, "field", w.Field}
+		if w.Raw != "" {
+			args = append(args, "raw", w.Raw)
+		}
+		c.log(ctx, slog.LevelWarn, w.Message, args...)
+	}
+}
+
+// indexedDegreePages returns the pages of the host of item from c.Index. The
+// host is crawled if it is not indexed or expired, and its news pages are
+// downloaded again if item is newer than them and not listed.
+func (c *Client) indexedDegreePages(ctx context.Context, item *News) ([]degreePage, error) {
+	h := c.Index.host(c, item.Link.Host)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var err error
+	if c.Index.expired(h) {
+		err = c.buildHostIndex(ctx, h, item.Link.Host)
+	} else if item.PubTime.After(h.refreshed) && degreesOf(h.pages, item.ID) == nil {
+		err = c.refreshHostIndex(ctx, h, item.Link.Host)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return h.pages, nil
+}
+
+// RefreshIndex downloads again the news pages of host into c.Index, crawling
+// the host from scratch if it is not indexed or expired
+func (c *Client) RefreshIndex(host string) error {
+	return c.RefreshIndexContext(context.Background(), host)
+}
+
+// RefreshIndexContext is like RefreshIndex but stops as soon as ctx is done
+func (c *Client) RefreshIndexContext(ctx context.Context, host string) error {
+	if c.Index == nil {
+		return errors.New("newstojson: client without index")
+	}
+	h := c.Index.host(c, host)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if c.Index.expired(h) {
+		return c.buildHostIndex(ctx, h, host)
+	}
+	return c.refreshHostIndex(ctx, h, host)
+}
@@ -0,0 +1,182 @@
+package newstojson
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingClient returns a fixture client with an index and a function
+// returning the number of course list and news page requests made so far
+func countingClient(t *testing.T) (*Client, func() (lists, pages int)) {
+	c := newFixtureClient(t)
+	c.Index = NewDegreeIndex(0)
+
+	var mu sync.Mutex
+	var lists, pages int
+	f := c.Fetcher
+	c.Fetcher = FetcherFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		if strings.Contains(req.URL.RawQuery, "tcs=") {
+			lists++
+		} else {
+			pages++
+		}
+		mu.Unlock()
+		return f.Do(req)
+	})
+	return c, func() (int, int) {
+		mu.Lock()
+		defer mu.Unlock()
+		return lists, pages
+	}
+}
+
+func TestDegreeIndex(t *testing.T) {
+	var tests = []struct {
+		link    string
+		degrees []int
+	}{
+		{"http://www.di.univr.it/?ent=avviso&dest=&id=119016", []int{419, 417}},
+		{"http://www.di.univr.it/?ent=avviso&dest=&id=123492", []int{420, 769}},
+		{"http://www.di.univr.it/?ent=avviso&dest=&id=118991", nil},
+		{"http://www.di.univr.it/?ent=avviso&dest=165&id=130134", []int{419, 417}},
+	}
+
+	c, count := countingClient(t)
+	for _, tt := range tests {
+		link, _ := url.Parse(tt.link)
+		item := newsFromLink(link)
+		err := c.SetIDsCourses(item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(item.DegreeIds, tt.degrees) {
+			t.Errorf("%s: expected %v, got %v", tt.link, tt.degrees, item.DegreeIds)
+		}
+	}
+
+	// One crawl for the whole host: 7 course lists and 8 news pages
+	if lists, pages := count(); lists != 7 || pages != 8 {
+		t.Errorf("Expected 7 course lists and 8 news pages, got %d and %d", lists, pages)
+	}
+	if degrees := c.Index.Degrees("www.di.univr.it", 119016); !reflect.DeepEqual(degrees, []int{419, 417}) {
+		t.Error("Unexpected degrees from the index", degrees)
+	}
+
+	// A news newer than the index and not listed downloads the news pages again
+	link, _ := url.Parse("http://www.di.univr.it/?ent=avviso&id=999999")
+	item := newsFromLink(link)
+	item.PubTime = time.Now().Add(time.Minute)
+	err := c.SetIDsCourses(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lists, pages := count(); lists != 7 || pages != 16 {
+		t.Errorf("Expected 7 course lists and 16 news pages, got %d and %d", lists, pages)
+	}
+
+	err = c.RefreshIndex("www.di.univr.it")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lists, pages := count(); lists != 7 || pages != 24 {
+		t.Errorf("Expected 7 course lists and 24 news pages, got %d and %d", lists, pages)
+	}
+}
+
+func TestDegreeIndexTTL(t *testing.T) {
+	c, count := countingClient(t)
+	c.Index.TTL = time.Nanosecond
+
+	link, _ := url.Parse("http://www.di.univr.it/?ent=avviso&dest=&id=119016")
+	for i := 0; i < 2; i++ {
+		err := c.SetIDsCourses(newsFromLink(link))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if lists, pages := count(); lists != 14 || pages != 16 {
+		t.Errorf("Expected two crawls, got %d course lists and %d news pages", lists, pages)
+	}
+
+	c.Index = nil
+	if err := c.RefreshIndex("www.di.univr.it"); err == nil {
+		t.Error("Expected error but got nil")
+	}
+}
+
+func TestDegreeIndexSharedClients(t *testing.T) {
+	c, count := countingClient(t)
+	other := NewClient(c.Fetcher)
+	other.Index = c.Index
+	other.CourseTypes = []CourseType{CourseMaster}
+
+	link, _ := url.Parse("http://www.di.univr.it/?ent=avviso&dest=&id=119016")
+	item := newsFromLink(link)
+	if err := c.SetIDsCourses(item); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(item.DegreeIds, []int{419, 417}) {
+		t.Error("Unexpected degrees", item.DegreeIds)
+	}
+
+	// The other client lists the master's degrees only, in a crawl of its own
+	item = newsFromLink(link)
+	if err := other.SetIDsCourses(item); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(item.DegreeIds, []int{417}) {
+		t.Error("Unexpected degrees of the other client", item.DegreeIds)
+	}
+	if lists, _ := count(); lists != 8 {
+		t.Errorf("Expected 8 course lists, got %d", lists)
+	}
+
+	// Both crawls are still used
+	item = newsFromLink(link)
+	if err := c.SetIDsCourses(item); err != nil {
+		t.Fatal(err)
+	}
+	if lists, _ := count(); lists != 8 || !reflect.DeepEqual(item.DegreeIds, []int{419, 417}) {
+		t.Error("Expected the first crawl to be reused, got", lists, item.DegreeIds)
+	}
+	if degrees := c.Index.Degrees("www.di.univr.it", 119016); len(degrees) != 2 {
+		t.Error("Expected the degrees of both crawls, got", degrees)
+	}
+}
+
+func TestDegreeIndexWarnings(t *testing.T) {
+	var buf bytes.Buffer
+	c, _ := countingClient(t)
+	c.Strict = true
+	c.Logger = slog.New(slog.NewTextHandler(&buf, nil))
+	f := c.Fetcher
+	c.Fetcher = FetcherFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Query().Get("tcs") == "N" {
+			return htmlResponse(req, `<div id="contenutoPagina"><div><dl>
+				<dt><a href="/?ent=cs&amp;id=385">Bioinformatics</a></dt>
+				<dt><a href="/?ent=cs">Removed degree</a></dt>
+			</dl></div></div>`), nil
+		}
+		return f.Do(req)
+	})
+
+	// The warnings of the crawl do not fail the news completed with it
+	link, _ := url.Parse("http://www.di.univr.it/?ent=avviso&dest=&id=119016")
+	for i := 0; i < 2; i++ {
+		item := newsFromLink(link)
+		if err := c.SetIDsCourses(item); err != nil || item.Warnings != nil {
+			t.Fatal("Expected no error and no warnings, got", err, item.Warnings)
+		}
+	}
+	if n := strings.Count(buf.String(), "course link without id skipped"); n != 1 {
+		t.Errorf("Expected the crawl warning to be logged once, got %d times in %q", n, buf.String())
+	}
+}
@@ -0,0 +1,160 @@
+package newstojson
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+)
+
+// newsJSON is the wire format of News
+type newsJSON struct {
+	ID              int          `json:"id"`
+	Title           string       `json:"title"`
+	Description     string       `json:"description,omitempty"`
+	Content         string       `json:"content"`
+	ContentHTML     string       `json:"content_html,omitempty"`
+	ContentMarkdown string       `json:"content_markdown,omitempty"`
+	Link            string       `json:"link"`
+	Attachments     []Attachment `json:"attachments"`
+	DipURL          string       `json:"dip_url,omitempty"`
+	Author          string       `json:"author"`
+	PubTime         string       `json:"pub_time"`
+	ModTime         string       `json:"mod_time,omitempty"`
+	Courses         []string     `json:"courses"`
+	DegreeIds       []int        `json:"degree_ids"`
+	Links           []Link       `json:"links,omitempty"`
+	Warnings        []Warning    `json:"warnings,omitempty"`
+
+	Translations map[string]LocalizedText `json:"translations,omitempty"`
+}
+
+// MarshalJSON encodes the news with snake_case field names. The link is
+// written as a string, the times in RFC 3339 format and mod_time is omitted
+// when the news was never modified. Empty lists are written as [], except
+// links, warnings and translations which are omitted.
+func (item News) MarshalJSON() ([]byte, error) {
+	out := newsJSON{
+		ID:              item.ID,
+		Title:           item.Title,
+		Description:     item.Description,
+		Content:         item.Content,
+		ContentHTML:     item.ContentHTML,
+		ContentMarkdown: item.ContentMarkdown,
+		Attachments:     item.Attachments,
+		DipURL:          item.DipURL,
+		Author:          item.Author,
+		PubTime:         item.PubTime.Format(time.RFC3339),
+		Courses:         item.Courses,
+		DegreeIds:       item.DegreeIds,
+		Links:           item.Links,
+		Warnings:        item.Warnings,
+	}
+	if item.Link != nil {
+		out.Link = item.Link.String()
+	}
+	if !item.ModTime.IsZero() {
+		out.ModTime = item.ModTime.Format(time.RFC3339)
+	}
+	if out.Attachments == nil {
+		out.Attachments = []Attachment{}
+	}
+	if out.Courses == nil {
+		out.Courses = []string{}
+	}
+	if out.DegreeIds == nil {
+		out.DegreeIds = []int{}
+	}
+	if item.Translations != nil {
+		out.Translations = make(map[string]LocalizedText, len(item.Translations))
+		for lang, text := range item.Translations {
+			if text.Courses == nil {
+				text.Courses = []string{}
+			}
+			out.Translations[lang] = text
+		}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes a news written by MarshalJSON
+func (item *News) UnmarshalJSON(data []byte) error {
+	var in newsJSON
+	err := json.Unmarshal(data, &in)
+	if err != nil {
+		return err
+	}
+
+	news := News{
+		ID:              in.ID,
+		Title:           in.Title,
+		Description:     in.Description,
+		Content:         in.Content,
+		ContentHTML:     in.ContentHTML,
+		ContentMarkdown: in.ContentMarkdown,
+		DipURL:          in.DipURL,
+		Author:          in.Author,
+	}
+	if in.Link != "" {
+		news.Link, err = url.Parse(in.Link)
+		if err != nil {
+			return err
+		}
+	}
+	if in.PubTime != "" {
+		news.PubTime, err = time.Parse(time.RFC3339, in.PubTime)
+		if err != nil {
+			return err
+		}
+	}
+	if in.ModTime != "" {
+		news.ModTime, err = time.Parse(time.RFC3339, in.ModTime)
+		if err != nil {
+			return err
+		}
+	}
+	if len(in.Attachments) > 0 {
+		news.Attachments = in.Attachments
+	}
+	if len(in.Courses) > 0 {
+		news.Courses = in.Courses
+	}
+	if len(in.DegreeIds) > 0 {
+		news.DegreeIds = in.DegreeIds
+	}
+	if len(in.Links) > 0 {
+		news.Links = in.Links
+	}
+	if len(in.Warnings) > 0 {
+		news.Warnings = in.Warnings
+	}
+	if len(in.Translations) > 0 {
+		news.Translations = in.Translations
+	}
+
+	*item = news
+	return nil
+}
+
+// attachmentJSON is the wire format of Attachment
+type attachmentJSON struct {
+	Title   string `json:"title"`
+	Link    string `json:"link"`
+	Preview string `json:"preview,omitempty"`
+}
+
+// MarshalJSON encodes the attachment with snake_case field names. The
+// preview is omitted when it was not downloaded.
+func (attach Attachment) MarshalJSON() ([]byte, error) {
+	return json.Marshal(attachmentJSON(attach))
+}
+
+// UnmarshalJSON decodes an attachment written by MarshalJSON
+func (attach *Attachment) UnmarshalJSON(data []byte) error {
+	var in attachmentJSON
+	err := json.Unmarshal(data, &in)
+	if err != nil {
+		return err
+	}
+	*attach = Attachment(in)
+	return nil
+}
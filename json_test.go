@@ -0,0 +1,82 @@
+package newstojson
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	link, _ := url.Parse("http://www.di.univr.it/?ent=avviso&id=119016&lang=eng")
+	loc, _ := time.LoadLocation("Europe/Rome")
+	item := News{
+		ID:          119016,
+		Title:       "Exam results",
+		Content:     "The results are available.",
+		Link:        link,
+		Attachments: []Attachment{{Title: "Results", Link: "http://www.di.univr.it/all.pdf"}},
+		Author:      "Mario Rossi",
+		PubTime:     time.Date(2016, 10, 31, 10, 26, 32, 0, loc),
+		Courses:     []string{"Algorithms (2016/2017)"},
+	}
+
+	res, err := json.Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"id":119016,"title":"Exam results","content":"The results are available.",` +
+		`"link":"http://www.di.univr.it/?ent=avviso\u0026id=119016\u0026lang=eng",` +
+		`"attachments":[{"title":"Results","link":"http://www.di.univr.it/all.pdf"}],` +
+		`"author":"Mario Rossi","pub_time":"2016-10-31T10:26:32+01:00",` +
+		`"courses":["Algorithms (2016/2017)"],"degree_ids":[]}`
+	if string(res) != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, res)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	link, _ := url.Parse("http://www.medicina.univr.it/fol/?ent=avviso&dest=25&id=119149")
+	loc, _ := time.LoadLocation("Europe/Rome")
+
+	var tests = []News{
+		{},
+		{
+			ID:          119149,
+			Title:       "Nursing internship",
+			Description: "Pubblicato da: Luisa Saiani",
+			Content:     "Students attending the internship must hand in the form.",
+			Link:        link,
+			Attachments: []Attachment{
+				{Title: "Form", Link: "http://www.medicina.univr.it/fol/all.pdf", Preview: "<p>Form</p>"},
+				{Title: "Rules", Link: "http://www.medicina.univr.it/fol/rules.pdf"},
+			},
+			DipURL:    "http://www.medicina.univr.it",
+			Author:    "Luisa Saiani",
+			PubTime:   time.Date(2016, 10, 24, 14, 17, 50, 0, loc),
+			ModTime:   time.Date(2016, 11, 2, 9, 0, 0, 0, loc),
+			Courses:   []string{"Nursing (Verona) (2016/2017)", "Cardiology (2016/2017)"},
+			DegreeIds: []int{101, 105},
+		},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(tt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var res News
+		err = json.Unmarshal(data, &res)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !res.PubTime.Equal(tt.PubTime) || !res.ModTime.Equal(tt.ModTime) {
+			t.Errorf("times: expected %v %v, got %v %v", tt.PubTime, tt.ModTime, res.PubTime, res.ModTime)
+		}
+		res.PubTime, res.ModTime = tt.PubTime, tt.ModTime
+		if !reflect.DeepEqual(res, tt) {
+			t.Errorf("Expected\n%+v\ngot\n%+v", tt, res)
+		}
+	}
+}
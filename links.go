@@ -0,0 +1,121 @@
+package newstojson
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// LinkKind classifies the links found in the body of a news
+type LinkKind string
+
+// Link kinds
+const (
+	LinkNotice   LinkKind = "notice"   // Another notice, /?ent=avviso&id=<id>
+	LinkCourse   LinkKind = "course"   // Page or notice list of a degree, /?ent=cs&id=<id> or /?ent=avvisoin&cs=<id>
+	LinkInternal LinkKind = "internal" // Any other page of a univr.it site, like Moodle
+	LinkExternal LinkKind = "external" // A page outside univr.it
+	LinkMail     LinkKind = "mailto"   // An email address
+	LinkMeeting  LinkKind = "meeting"  // An online meeting room, like Zoom or Teams
+)
+
+// Link is a link found in the body of a news
+type Link struct {
+	Text string   `json:"text"` // Text of the anchor, the address itself for a bare URL
+	URL  string   `json:"url"`  // Absolute address
+	Kind LinkKind `json:"kind"`
+}
+
+// meetingHosts are the domains of the online meeting services
+var meetingHosts = []string{
+	"zoom.us", "meet.google.com", "teams.microsoft.com", "teams.live.com",
+	"webex.com", "meet.jit.si", "gotomeeting.com", "whereby.com",
+}
+
+// bareURLRegexp matches the addresses written as text
+var bareURLRegexp = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"]+`)
+
+// linkSchemes are the schemes of the links kept in Links
+var linkSchemes = []string{"http", "https", "mailto"}
+
+// extractLinks returns the anchors and bare URLs found in nodes, resolved
+// against base, without duplicates
+func extractLinks(nodes []*html.Node, base *url.URL) []Link {
+	var res []Link
+	seen := map[string]bool{}
+	add := func(text, ref string) {
+		u, err := url.Parse(strings.TrimSpace(ref))
+		if err != nil {
+			return
+		}
+		if base != nil {
+			u = base.ResolveReference(u)
+		}
+		if !containsString(linkSchemes, strings.ToLower(u.Scheme)) || seen[u.String()] {
+			return
+		}
+		seen[u.String()] = true
+		text = removeExtraSpaces(text)
+		if text == "" {
+			text = strings.TrimPrefix(u.String(), "mailto:")
+		}
+		res = append(res, Link{Text: text, URL: u.String(), Kind: linkKind(u)})
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			for _, m := range bareURLRegexp.FindAllString(n.Data, -1) {
+				m = strings.TrimRight(m, ".,;:!?)]'")
+				ref := m
+				if strings.HasPrefix(strings.ToLower(m), "www.") {
+					ref = "http://" + m
+				}
+				add(m, ref)
+			}
+		case n.Type != html.ElementNode || containsString(droppedElements, n.Data):
+		case n.Data == "a":
+			if href, ok := attr(n, "href"); ok {
+				add(textOf(n), href)
+				return
+			}
+			fallthrough
+		default:
+			for child := n.FirstChild; child != nil; child = child.NextSibling {
+				walk(child)
+			}
+		}
+	}
+	for _, n := range nodes {
+		walk(n)
+	}
+	return res
+}
+
+// linkKind classifies the absolute link u
+func linkKind(u *url.URL) LinkKind {
+	if strings.EqualFold(u.Scheme, "mailto") {
+		return LinkMail
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, domain := range meetingHosts {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return LinkMeeting
+		}
+	}
+	if host != "univr.it" && !strings.HasSuffix(host, ".univr.it") {
+		return LinkExternal
+	}
+
+	q := u.Query()
+	switch {
+	case q.Get("ent") == "avviso" && q.Get("id") != "":
+		return LinkNotice
+	case q.Get("ent") == "cs" && q.Get("id") != "", q.Get("ent") == "avvisoin" && q.Get("cs") != "":
+		return LinkCourse
+	}
+	return LinkInternal
+}
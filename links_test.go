@@ -0,0 +1,43 @@
+package newstojson
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestExtractLinks(t *testing.T) {
+	base, _ := url.Parse("http://www.di.univr.it/?ent=avviso&id=119016")
+	page := `<div id="content">
+		<p>See <a href="?ent=avviso&amp;id=118037">the previous notice</a> and the
+		<a href="/?ent=cs&id=420">degree</a> (<a href="http://www.di.univr.it/?ent=avvisoin&cs=420">notices</a>).</p>
+		<p>Slides on <a href="https://moodledidattica.univr.it/course/view.php?id=1">Moodle</a>,
+		lesson on https://univr.zoom.us/j/123456789. Register at www.example.com/form!</p>
+		<p>Write to <a href="mailto:info@di.univr.it">the secretariat</a>, not <a href="javascript:alert(1)">here</a>.
+		<a href="https://teams.microsoft.com/l/meetup-join/1"><img src="/teams.png"></a>
+		<a href="?ent=avviso&id=118037">again</a></p>
+		<script>var u = "https://evil.example.com";</script>
+	</div>`
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Link{
+		{"the previous notice", "http://www.di.univr.it/?ent=avviso&id=118037", LinkNotice},
+		{"degree", "http://www.di.univr.it/?ent=cs&id=420", LinkCourse},
+		{"notices", "http://www.di.univr.it/?ent=avvisoin&cs=420", LinkCourse},
+		{"Moodle", "https://moodledidattica.univr.it/course/view.php?id=1", LinkInternal},
+		{"https://univr.zoom.us/j/123456789", "https://univr.zoom.us/j/123456789", LinkMeeting},
+		{"www.example.com/form", "http://www.example.com/form", LinkExternal},
+		{"the secretariat", "mailto:info@di.univr.it", LinkMail},
+		{"https://teams.microsoft.com/l/meetup-join/1", "https://teams.microsoft.com/l/meetup-join/1", LinkMeeting},
+	}
+	links := extractLinks(doc.Find("#content").Nodes, base)
+	if !reflect.DeepEqual(links, expected) {
+		t.Errorf("Expected %v, got %v", expected, links)
+	}
+}
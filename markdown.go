@@ -0,0 +1,281 @@
+package newstojson
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ============================================================================
+// Markdown
+// ============================================================================
+
+// markdownWriter converts HTML to CommonMark. Tables are written as pipe
+// tables, the GitHub extension understood by most renderers.
+type markdownWriter struct {
+	policy *Policy  // Elements to drop and schemes of the links
+	base   *url.URL // Base of the relative links
+}
+
+// blockElements start a new block of Markdown
+var blockElements = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "center": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "dl": true, "table": true, "pre": true, "blockquote": true, "hr": true,
+}
+
+// markdownEscaper escapes the characters with a meaning in Markdown
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`,
+	`<`, `\<`, `>`, `\>`, `#`, `\#`, `|`, `\|`, `&`, `\&`, `~`, `\~`,
+)
+
+// destinationEscaper escapes the characters ending a link destination
+var destinationEscaper = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29")
+
+// lineStartRegexp matches the start of a line read as a list item
+var lineStartRegexp = regexp.MustCompile(`^([-+=]|\d+[.)])`)
+
+// markdown returns the children of nodes as Markdown, with the links resolved
+// against base
+func (p *Policy) markdown(nodes []*html.Node, base *url.URL) string {
+	w := markdownWriter{policy: p, base: base}
+	var blocks []string
+	for _, n := range nodes {
+		blocks = append(blocks, w.blocks(n)...)
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// blocks returns the Markdown blocks of the children of n
+func (w *markdownWriter) blocks(n *html.Node) []string {
+	var res []string
+	var text strings.Builder
+	flush := func() {
+		if block := paragraph(text.String()); block != "" {
+			res = append(res, block)
+		}
+		text.Reset()
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || !blockElements[child.Data] {
+			text.WriteString(w.inline(child))
+			continue
+		}
+		flush()
+
+		var block string
+		switch tag := child.Data; tag {
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			level, _ := strconv.Atoi(tag[1:])
+			if title := strings.TrimSpace(strings.ReplaceAll(w.inline(child), "\\\n", " ")); title != "" {
+				block = strings.Repeat("#", level) + " " + title
+			}
+		case "ul", "ol":
+			block = w.list(child, tag == "ol")
+		case "dl":
+			block = w.definitions(child)
+		case "table":
+			block = w.table(child)
+		case "pre":
+			block = "```\n" + strings.Trim(textOf(child), "\n") + "\n```"
+		case "blockquote":
+			block = "> " + strings.ReplaceAll(strings.Join(w.blocks(child), "\n\n"), "\n", "\n> ")
+		case "hr":
+			block = "---"
+		default:
+			res = append(res, w.blocks(child)...)
+		}
+		if block != "" {
+			res = append(res, block)
+		}
+	}
+	flush()
+	return res
+}
+
+// paragraph trims the lines of text and escapes those that would start a list
+func paragraph(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\\\n")
+	var res []string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if m := lineStartRegexp.FindString(line); m != "" {
+			line = m[:len(m)-1] + `\` + line[len(m)-1:]
+		}
+		res = append(res, line)
+	}
+	return strings.Join(res, "\\\n")
+}
+
+// inline returns the Markdown of n inside a paragraph
+func (w *markdownWriter) inline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return markdownEscaper.Replace(spacesRegexp.ReplaceAllString(n.Data, " "))
+	case html.ElementNode:
+	default:
+		return ""
+	}
+	if w.policy.dropped(n.Data) {
+		return ""
+	}
+
+	var inner strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		inner.WriteString(w.inline(child))
+	}
+	content := inner.String()
+
+	switch n.Data {
+	case "br":
+		return "\\\n"
+	case "strong", "b":
+		return emphasis(content, "**")
+	case "em", "i":
+		return emphasis(content, "*")
+	case "code":
+		if code := textOf(n); code != "" {
+			return "`` " + code + " ``"
+		}
+	case "a":
+		href, ok := attr(n, "href")
+		if ok {
+			href, ok = w.policy.resolve(href, w.base)
+		}
+		if !ok {
+			return content
+		}
+		href = destinationEscaper.Replace(href)
+		if strings.TrimSpace(content) == "" {
+			return "<" + href + ">"
+		}
+		return "[" + strings.TrimSpace(content) + "](" + href + ")"
+	case "img":
+		src, ok := attr(n, "src")
+		if ok {
+			src, ok = w.policy.resolve(src, w.base)
+		}
+		if !ok {
+			return ""
+		}
+		alt, _ := attr(n, "alt")
+		return "![" + markdownEscaper.Replace(alt) + "](" + destinationEscaper.Replace(src) + ")"
+	}
+	return content
+}
+
+// emphasis wraps content in marker, leaving its spaces outside so that the
+// Markdown stays valid
+func emphasis(content, marker string) string {
+	text := strings.TrimSpace(content)
+	if text == "" {
+		return content
+	}
+	lead := content[:strings.Index(content, text)]
+	trail := content[len(lead)+len(text):]
+	return lead + marker + text + marker + trail
+}
+
+// list returns the items of the ul or ol n, one per line, with the lines of
+// an item indented below its marker
+func (w *markdownWriter) list(n *html.Node, ordered bool) string {
+	var items []string
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || child.Data != "li" {
+			continue
+		}
+		marker := "- "
+		if ordered {
+			marker = strconv.Itoa(len(items)+1) + ". "
+		}
+		content := strings.Join(w.blocks(child), "\n")
+		indent := strings.Repeat(" ", len(marker))
+		items = append(items, marker+strings.ReplaceAll(content, "\n", "\n"+indent))
+	}
+	return strings.Join(items, "\n")
+}
+
+// definitions returns the terms of the dl n in bold, followed by their
+// descriptions
+func (w *markdownWriter) definitions(n *html.Node) string {
+	var res []string
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+		text := strings.Join(w.blocks(child), "\n\n")
+		if text == "" {
+			continue
+		}
+		if child.Data == "dt" {
+			text = emphasis(text, "**")
+		}
+		res = append(res, text)
+	}
+	return strings.Join(res, "\n\n")
+}
+
+// table returns the table n as a pipe table whose header is the first row
+func (w *markdownWriter) table(n *html.Node) string {
+	var rows [][]string
+	columns := 0
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode || child.Data == "table" {
+				continue
+			}
+			if child.Data != "tr" {
+				walk(child)
+				continue
+			}
+			var row []string
+			for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type == html.ElementNode && (cell.Data == "td" || cell.Data == "th") {
+					text := strings.Join(w.blocks(cell), " ")
+					row = append(row, strings.ReplaceAll(text, "\\\n", " "))
+				}
+			}
+			if len(row) > columns {
+				columns = len(row)
+			}
+			rows = append(rows, row)
+		}
+	}
+	walk(n)
+	if columns == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			b.WriteString(strings.Repeat("| --- ", columns) + "|\n")
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// textOf returns the text of n and of its descendants
+func textOf(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(textOf(child))
+	}
+	return b.String()
+}
@@ -0,0 +1,42 @@
+package newstojson
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestMarkdown(t *testing.T) {
+	base, _ := url.Parse("http://www.di.univr.it/?ent=avviso&id=119016")
+	var tests = []struct {
+		html     string
+		expected string
+	}{
+		{`<p>First</p><p>Second<br>line</p>`, "First\n\nSecond\\\nline"},
+		{`Text <strong>bold </strong>and <em>italic</em>`, "Text **bold** and *italic*"},
+		{`<a href="?ent=avviso&id=1">Notice</a> <a href="javascript:alert(1)">JS</a>`, "[Notice](http://www.di.univr.it/?ent=avviso&id=1) JS"},
+		{`<a href="mailto:info@univr.it"></a>`, "<mailto:info@univr.it>"},
+		{`<a href="/doc (1).pdf">PDF</a>`, "[PDF](http://www.di.univr.it/doc%20%281%29.pdf)"},
+		{`2 * 3 = 6, a_b [c] &lt;d&gt; #1 | x`, `2 \* 3 = 6, a\_b \[c\] \<d\> \#1 \| x`},
+		{`<p>- not a list</p><p>1. not a list</p>`, "\\- not a list\n\n1\\. not a list"},
+		{`<h2>Title</h2><ul><li>One</li><li>Two<ul><li>Nested</li></ul></li></ul>`, "## Title\n\n- One\n- Two\n  - Nested"},
+		{`<ol><li>First</li><li>Second</li></ol>`, "1. First\n2. Second"},
+		{`<table><tr><th>Name</th><th>Mark</th></tr><tr><td>Rossi</td><td>27</td></tr><tr><td>Bianchi</td></tr></table>`,
+			"| Name | Mark |\n| --- | --- |\n| Rossi | 27 |\n| Bianchi |  |"},
+		{`<pre>a  *b*</pre><script>alert(1)</script>`, "```\na  *b*\n```"},
+		{`<div><span>Text</span></div><blockquote><p>Quote</p></blockquote>`, "Text\n\n> Quote"},
+		{`<img src="/logo.png" alt="Logo">`, "![Logo](http://www.di.univr.it/logo.png)"},
+	}
+	for _, tt := range tests {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<div id="content">` + tt.html + `</div>`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := ContentPolicy.markdown(doc.Find("#content").Nodes, base)
+		if res != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.html, tt.expected, res)
+		}
+	}
+}
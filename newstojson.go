@@ -1,9 +1,9 @@
 package newstojson
 
 import (
-	"errors"
-	"io/ioutil"
-	"net/http"
+	"context"
+	"fmt"
+	"io"
 	"net/url"
 	"regexp"
 	"strconv"
@@ -12,10 +12,8 @@ import (
 	"unicode"
 
 	"github.com/PuerkitoBio/goquery"
-	log "github.com/Sirupsen/logrus"
 	rss "github.com/jteeuwen/go-pkg-rss"
-	"github.com/tdewolff/minify"
-	mhtml "github.com/tdewolff/minify/html"
+	"golang.org/x/net/html"
 )
 
 // Attachment file to the news
@@ -27,18 +25,26 @@ type Attachment struct {
 
 // News represents single news
 type News struct {
-	ID          int
-	Title       string
-	Description string
-	Content     string
-	Link        *url.URL
-	Attachments []Attachment
-	DipURL      string
-	Author      string
-	PubTime     time.Time // Pubblication time
-	ModTime     time.Time // Modification time
-	Courses     []string
-	DegreeIds   []int // Lauree a cui e' rivolto l'avviso
+	ID              int
+	Title           string
+	Description     string
+	Content         string
+	ContentHTML     string // Content with its formatting, sanitized and with absolute links
+	ContentMarkdown string // Content with its formatting as CommonMark, for the chat bots
+	Link            *url.URL
+	Attachments     []Attachment
+	DipURL          string
+	Author          string
+	PubTime         time.Time // Pubblication time
+	ModTime         time.Time // Modification time
+	Courses         []string
+	DegreeIds       []int     // Lauree a cui e' rivolto l'avviso
+	Links           []Link    // Links found in the content
+	Warnings        []Warning // Problems found while parsing
+
+	// Translations holds the text of the news by language, like eng and ita,
+	// if the client was asked for more Languages
+	Translations map[string]LocalizedText
 }
 
 // =============================================================================
@@ -47,6 +53,36 @@ type News struct {
 
 // Parse function to parse rss item passes as argument
 func Parse(rssitem *rss.Item) (*News, error) {
+	return DefaultClient.Parse(rssitem)
+}
+
+// ParseContext is like Parse but stops as soon as ctx is done
+func ParseContext(ctx context.Context, rssitem *rss.Item) (*News, error) {
+	return DefaultClient.ParseContext(ctx, rssitem)
+}
+
+// ParseFromLink parse a new from a direct link
+func ParseFromLink(link *url.URL) (*News, error) {
+	return DefaultClient.ParseFromLink(link)
+}
+
+// ParseFromLinkContext is like ParseFromLink but stops as soon as ctx is done
+func ParseFromLinkContext(ctx context.Context, link *url.URL) (*News, error) {
+	return DefaultClient.ParseFromLinkContext(ctx, link)
+}
+
+// CompleteParse retrive all the informations
+func (item *News) CompleteParse() error {
+	return DefaultClient.CompleteParse(item)
+}
+
+// CompleteParseContext is like CompleteParse but stops as soon as ctx is done
+func (item *News) CompleteParseContext(ctx context.Context) error {
+	return DefaultClient.CompleteParseContext(ctx, item)
+}
+
+// newsFromItem builds a news from the fields of the rss item
+func newsFromItem(rssitem *rss.Item) (*News, error) {
 	var err error
 	news := new(News)
 
@@ -62,52 +98,36 @@ func Parse(rssitem *rss.Item) (*News, error) {
 	// Description
 	news.Description = rssitem.Description
 
-	// Link
-	news.Link, err = url.Parse(rssitem.Links[0].Href)
-	if err != nil {
-		return nil, err
+	// Link and news ID, from the guid if the link is missing
+	var link, guid string
+	if len(rssitem.Links) > 0 {
+		link = rssitem.Links[0].Href
 	}
-
-	// Retrive news ID
-	m, _ := url.ParseQuery(news.Link.RawQuery)
-	news.ID, _ = strconv.Atoi(m.Get("id"))
-
-	// Retrive other infos
-	err = news.GetContentFromURL()
-	if err != nil {
-		return nil, err
+	if rssitem.Guid != nil {
+		guid = *rssitem.Guid
+	}
+	warnings, ok := news.setLinkAndID(link, guid)
+	if !ok {
+		return nil, ErrInvalidNewsURL
 	}
+	news.Warnings = append(news.Warnings, warnings...)
 
-	// Return the new obj
 	return news, nil
 }
 
-// ParseFromLink parse a new from a direct link
-func ParseFromLink(link *url.URL) (*News, error) {
+// newsFromLink builds a news from a direct link
+func newsFromLink(link *url.URL) *News {
 	news := new(News)
 	news.Link = link
 
 	// Retrive news ID
 	m, _ := url.ParseQuery(news.Link.RawQuery)
 	news.ID, _ = strconv.Atoi(m.Get("id"))
-
-	// Retrive other infos
-	err := news.GetContentFromURL()
-	if err != nil {
-		return nil, err
+	if news.ID == 0 {
+		news.warn("id", "no id in the link", link.String())
 	}
 
-	return news, nil
-}
-
-// CompleteParse retrive all the informations
-func (item *News) CompleteParse() error {
-	// Get all IDs courses
-	err := item.SetIDsCourses()
-	if err != nil {
-		return err
-	}
-	return nil
+	return news
 }
 
 // =============================================================================
@@ -119,120 +139,177 @@ func (item *News) IsNew(activationTime time.Time) bool {
 	return activationTime.UTC().Before(item.PubTime.UTC()) || activationTime.UTC().Equal(item.PubTime.UTC()) || activationTime.UTC().Before(item.ModTime.UTC()) || activationTime.UTC().Equal(item.ModTime.UTC())
 }
 
+// ParseHTML parses a news page read from r without touching the network.
+// link is the address the page was taken from and is used to set the news ID
+// and to resolve the attachments; it is required, a nil link returns
+// ErrInvalidNewsURL. Attachment previews are not downloaded.
+func ParseHTML(r io.Reader, link *url.URL) (*News, error) {
+	c := &Client{SkipPreviews: true}
+	return c.ParseHTMLContext(context.Background(), r, link)
+}
+
+// ParseHTMLContext is like the package level ParseHTML but downloads the
+// attachment previews with c, unless c.SkipPreviews is set
+func (c *Client) ParseHTMLContext(ctx context.Context, r io.Reader, link *url.URL) (*News, error) {
+	if link == nil {
+		return nil, ErrInvalidNewsURL
+	}
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	doc.Url = link
+
+	news := newsFromLink(link)
+	err = c.parseDocument(ctx, news, doc, !c.SkipPreviews)
+	if err != nil {
+		return nil, err
+	}
+
+	return news, nil
+}
+
 // GetContentFromURL builds content and files attached to the news
 func (item *News) GetContentFromURL() error {
-	// Minifier tool to delete extra whitespaces
-	m := minify.New()
-	m.AddFunc("text/html", mhtml.Minify)
+	return DefaultClient.GetContentFromURL(item)
+}
 
-	link := item.Link
+// GetContentFromURLContext is like GetContentFromURL but stops as soon as ctx
+// is done
+func (item *News) GetContentFromURLContext(ctx context.Context) error {
+	return DefaultClient.GetContentFromURLContext(ctx, item)
+}
 
-	l, _ := url.ParseQuery(item.Link.RawQuery)
-	if len(l["lang"]) > 1 {
-		if strings.Compare(l.Get("lang"), "eng") != 0 {
-			l.Del("lang")
-			l.Set("lang", "eng")
-		}
-	} else {
-		l.Set("lang", "eng")
-	}
-	link.RawQuery = l.Encode()
+// GetContentFromURL builds content and files attached to item using c
+func (c *Client) GetContentFromURL(item *News) error {
+	return c.GetContentFromURLContext(context.Background(), item)
+}
 
-	baseURL := "http://" + item.Link.Host
+// GetContentFromURLContext is like GetContentFromURL but stops as soon as ctx
+// is done
+func (c *Client) GetContentFromURLContext(ctx context.Context, item *News) error {
+	item.Link.RawQuery = newsPageURL(item.Link, c.language()).RawQuery
 
-	doc, err := goquery.NewDocument(link.String())
+	doc, err := c.document(ctx, item.Link.String())
 
 	if err != nil {
 		return err
 	}
-	// Setto il contenuto dell'avviso
-	if doc.Find(".main-text").Text() != "" {
-		item.Content, err = m.String("text/html", doc.Find(".main-text").Text())
-		if err != nil {
-			return err
+
+	err = c.parseDocument(ctx, item, doc, !c.SkipPreviews)
+	if err != nil || len(c.Languages) == 0 {
+		return err
+	}
+	return c.setTranslations(ctx, item)
+}
+
+// parseDocument builds content and files attached to item from the news page
+// doc. Attachment previews are downloaded only if previews is true.
+func (c *Client) parseDocument(ctx context.Context, item *News, doc *goquery.Document, previews bool) error {
+	var err error
+	from := len(item.Warnings)
+
+	baseURL := "http://" + item.Link.Host
+	profile := c.ProfileFor(item.Link.Host)
+
+	// Check that the page is still a news page
+	for _, selector := range []string{profile.Title, profile.Details, strings.Join(profile.Content, ", ")} {
+		if doc.Find(selector).Length() == 0 {
+			return layoutChanged(item.Link.String(), selector)
 		}
-	} else {
-		item.Content, err = m.String("text/html", doc.Find(".sezione").Text())
-		if err != nil {
-			return err
+	}
+
+	// Setto il contenuto dell'avviso
+	for _, selector := range profile.Content {
+		content := doc.Find(selector)
+		if text := content.Text(); text != "" {
+			item.Content = removeExtraSpaces(text)
+			item.ContentHTML = c.contentPolicy().sanitize(content.Nodes, item.Link)
+			item.ContentMarkdown = c.contentPolicy().markdown(content.Nodes, item.Link)
+			item.Links = extractLinks(content.Nodes, item.Link)
+			break
 		}
 	}
-	item.Title = doc.Find("h1").Text()
+	item.Title = doc.Find(profile.Title).Text()
 
 	action := ""
-	doc.Find("#dettagliAvviso").Children().Each(func(i int, s *goquery.Selection) {
+	var lang PageLanguage // language of the last label
+	doc.Find(profile.Details).Children().Each(func(i int, s *goquery.Selection) {
 		if action == "pubDate" && s.Is("dd") {
-			value := SpaceMap(strings.TrimSpace(s.Text()))
-			layout := "Monday,January2,2006-15:4:5PM"
-			loc, _ := time.LoadLocation("Europe/Rome")
-			item.PubTime, _ = time.ParseInLocation(layout, value, loc)
+			item.PubTime, err = parseDetailsDate(s.Text(), lang)
+			if err != nil {
+				item.warnErr("pub_time", "", err, strings.TrimSpace(s.Text()))
+			}
 			action = ""
 		} else if action == "modDate" && s.Is("dd") {
-			value := SpaceMap(strings.TrimSpace(s.Text()))
-			layout := "Monday,January2,2006-15:4:5PM"
-			loc, _ := time.LoadLocation("Europe/Rome")
-			item.ModTime, _ = time.ParseInLocation(layout, value, loc)
+			item.ModTime, err = parseDetailsDate(s.Text(), lang)
+			if err != nil {
+				item.warnErr("mod_time", "", err, strings.TrimSpace(s.Text()))
+			}
 			action = ""
 		} else if action == "author" && s.Is("dd") {
-			html, errIn := s.Html()
-			if err != nil {
-				log.Errorln(errIn)
+			// Only the text is kept, the author and the courses are
+			// emitted as plain strings
+			res := brLines(s.Nodes)
+			if len(res) == 0 {
+				item.warn("author", "no author", strings.TrimSpace(s.Text()))
+				return
 			}
-			res := HtmlBRDivisorTOArray(html)
 			item.Author = res[0]
 			item.Courses = res[1:]
-			for i, course := range item.Courses {
-				item.Courses[i], err = m.String("text/html", course)
-				if err != nil {
-					log.Errorln(err)
-				}
-			}
 
 		} else {
 			action = ""
 		}
 
 		current := strings.TrimSpace(s.Text())
-		if current == "Publication date" {
-			action = "pubDate"
-		} else if current == "Last Modified" {
-			action = "modDate"
-		} else if current == "Published by" {
-			action = "author"
+		for _, l := range profile.Languages {
+			if current == l.PubTime {
+				action = "pubDate"
+			} else if current == l.ModTime {
+				action = "modDate"
+			} else if current == l.Author {
+				action = "author"
+			} else {
+				continue
+			}
+			lang = l
+			break
 		}
 	})
 
 	// Searching for Attachments
-	attach := Attachment{}
-	doc.Find(".formati").Find("li").Each(func(i int, s *goquery.Selection) {
-		attach.Title, err = m.String("text/html", s.Text())
-		if err != nil {
-			log.Errorln(err)
-		}
-		attach.Title = removeExtraSpaces(attach.Title)
+	doc.Find(profile.Attachments).Each(func(i int, s *goquery.Selection) {
+		var attach Attachment
+		attach.Title = removeExtraSpaces(s.Text())
 
 		linkAllegato, isPresent := s.Find("a").Attr("href")
 
 		if isPresent {
 			attach.Link = baseURL + linkAllegato
 
+			// The preview link is the second argument of apriAnteprima
 			onclickString, isPresent := s.Find("a").Attr("onclick")
 			if isPresent {
-				attach.Link, err = m.String("text/html", baseURL+strings.Split(onclickString, "'")[3])
-				if err != nil {
-					log.Errorln(err)
+				parts := strings.Split(onclickString, "'")
+				if len(parts) < 4 {
+					item.warn("attachments", "no preview link in onclick", onclickString)
+					isPresent = false
+				} else {
+					attach.Link = baseURL + parts[3]
 				}
+			}
 
-				resp, err := http.Get(attach.Link)
+			if isPresent && previews {
+				body, err := c.readAll(ctx, attach.Link)
+				if ctx.Err() != nil {
+					return
+				}
 				if err != nil {
-					log.Errorln("Error on response : " + err.Error())
+					item.warnErr("attachments", "preview not downloaded", err, attach.Link)
 				} else {
-					defer resp.Body.Close()
-					body, _ := ioutil.ReadAll(resp.Body)
-					attach.Preview, err = m.String("text/html", string(body))
-					if err != nil {
-						log.Errorln(err)
-					}
+					base, _ := url.Parse(attach.Link)
+					attach.Preview = c.previewPolicy().Sanitize(string(body), base)
 				}
 			}
 		}
@@ -240,47 +317,64 @@ func (item *News) GetContentFromURL() error {
 	})
 	// End Searching for Attachments
 
-	return nil
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.report(ctx, item, from)
 }
 
 // SetIDsCourses sets id courses
 func (item *News) SetIDsCourses() error {
-	var newsPageList []string
+	return DefaultClient.SetIDsCourses(item)
+}
+
+// SetIDsCoursesContext is like SetIDsCourses but stops as soon as ctx is done
+func (item *News) SetIDsCoursesContext(ctx context.Context) error {
+	return DefaultClient.SetIDsCoursesContext(ctx, item)
+}
+
+// SetIDsCourses sets id courses of item using c
+func (c *Client) SetIDsCourses(item *News) error {
+	return c.SetIDsCoursesContext(context.Background(), item)
+}
+
+// SetIDsCoursesContext is like SetIDsCourses but stops as soon as ctx is done
+func (c *Client) SetIDsCoursesContext(ctx context.Context, item *News) error {
+	from := len(item.Warnings)
+
+	var pages []degreePage
 	var err error
-	// Get all news pages
-	if strings.Contains(item.Link.Host, "medicina") {
-		newsPageList, err = getNewsPagesFromHostMedicina(item.Link.Host)
+	if c.Index != nil {
+		// The warnings of the crawl are logged when it is made
+		pages, err = c.indexedDegreePages(ctx, item)
 	} else {
-		newsPageList, err = getNewsPagesFromHost(item.Link.Host)
+		var warnings []Warning
+		pages, warnings, err = c.degreePages(ctx, item.Link.Host)
+		item.Warnings = append(item.Warnings, warnings...)
+		item.Warnings = append(item.Warnings, pageWarnings(pages)...)
 	}
 	if err != nil {
 		return err
 	}
-	for _, val := range newsPageList {
-		//Recupero gli ultimi 5 avvisi da ogni corso e vedo dove e' presente
-		ids, err := RetriveLast5NewsIDsFromNewsPage(val)
-		if err != nil {
-			return err
-		}
-		if contains(ids, item.ID) {
-			item.DegreeIds = append(item.DegreeIds, getIDFromCompleteURL(val))
-		}
-	}
 
-	return nil
+	item.DegreeIds = append(item.DegreeIds, degreesOf(pages, item.ID)...)
+
+	return c.report(ctx, item, from)
 }
 
 // SetIDFromURL set the news's ID from the direct link
 func (item *News) SetIDFromURL(url string) error {
+	var ok bool
 	if strings.Contains(url, "avviso") {
 		if strings.Contains(url, "univr.it") {
 			// host gia' presente
-			item.ID = getIDFromCompleteURL(url)
+			item.ID, ok = getIDFromCompleteURL(url)
 		} else {
-			item.ID = getIDFromCompleteURL("wwww.example.com" + url)
+			item.ID, ok = getIDFromCompleteURL("wwww.example.com" + url)
 		}
-	} else {
-		return errors.New("No valid url")
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrInvalidNewsURL, url)
 	}
 	return nil
 }
@@ -289,149 +383,141 @@ func (item *News) SetIDFromURL(url string) error {
 // HTML utils functions
 // =============================================================================
 
-func getNewsPagesFromHost(host string) ([]string, error) {
-	var res []string
-	coursesType := []string{
-		"N",
-		"MA",
-		"mu",
-		"SP",
-		"R",
-		"F",
-		"T",
-	}
-	for _, courseType := range coursesType {
-		tmpRes, err := NewsPageLinksFromURLCorso(host + "/?ent=cs&tcs=" + courseType)
-		if err != nil {
-			return nil, err
-		}
-		res = append(res, tmpRes...)
-	}
-	return res, nil
+// NewsPages returns the links to the news pages of all the degrees of host
+func NewsPages(host string) ([]string, error) {
+	return DefaultClient.NewsPages(host)
 }
 
-// getNewsPagesFromHostMedicina recupera i link delle pagine
-func getNewsPagesFromHostMedicina(host string) ([]string, error) {
-	var res []string
-	coursesType := []string{
-		"N",
-		"MA",
-		"mu",
-		"SP",
-		"R",
-		"F",
-		"T",
-	}
-	for _, courseType := range coursesType {
-		tmpRes, err := NewsPageLinksFromURLCorsoMedicina(host + "/?ent=cs&tcs=" + courseType)
-		if err != nil {
-			return nil, err
-		}
-		res = append(res, tmpRes...)
+// NewsPages is like the package level NewsPages but uses c for the requests
+func (c *Client) NewsPages(host string) ([]string, error) {
+	return c.NewsPagesContext(context.Background(), host)
+}
+
+// NewsPagesContext is like NewsPages but stops as soon as ctx is done
+func (c *Client) NewsPagesContext(ctx context.Context, host string) ([]string, error) {
+	return c.newsPages(ctx, host, nil)
+}
+
+// newsPages returns the links to the news pages of host, adding to warnings
+// the links that were skipped
+func (c *Client) newsPages(ctx context.Context, host string, warnings *[]Warning) ([]string, error) {
+	return c.getNewsPagesFromHost(ctx, host, warnings)
+}
+
+func (c *Client) getNewsPagesFromHost(ctx context.Context, host string, warnings *[]Warning) ([]string, error) {
+	degrees, err := c.catalog(ctx, host, warnings)
+	if err != nil {
+		return nil, err
 	}
-	return res, nil
+	return newsPageURLs(degrees, c.ProfileFor(host).SkipDiscontinued), nil
 }
 
 // NewsPageLinksFromURLCorso Ritorna la lista dei link alle pagine che contengono gli avvisi del
 // dipartimento passato come parametro a partire dall'url che contiene
 // tutte le laure del corso
 func NewsPageLinksFromURLCorso(urlString string) ([]string, error) {
-	var res []string
-
-	urlString = "http://" + urlString
+	return DefaultClient.NewsPageLinksFromURLCorso(urlString)
+}
 
-	rootURL, err := url.Parse(urlString)
-	if err != nil {
-		return nil, err
-	}
+// NewsPageLinksFromURLCorso is like the package level NewsPageLinksFromURLCorso
+// but uses c for the requests
+func (c *Client) NewsPageLinksFromURLCorso(urlString string) ([]string, error) {
+	return c.NewsPageLinksFromURLCorsoContext(context.Background(), urlString)
+}
 
-	doc, err := goquery.NewDocument(urlString)
+// NewsPageLinksFromURLCorsoContext is like NewsPageLinksFromURLCorso but stops
+// as soon as ctx is done
+func (c *Client) NewsPageLinksFromURLCorsoContext(ctx context.Context, urlString string) ([]string, error) {
+	return c.newsPageLinksFromURLCorso(ctx, urlString, nil)
+}
 
+func (c *Client) newsPageLinksFromURLCorso(ctx context.Context, urlString string, warnings *[]Warning) ([]string, error) {
+	profile := c.ProfileFor(strings.SplitN(urlString, "/", 2)[0])
+	degrees, err := c.courseList(ctx, urlString, profile, warnings)
 	if err != nil {
 		return nil, err
 	}
-	doc.Find("#contenutoPagina").Find("div").First().Find("dl").Find("dt").Find("a").Each(func(i int, s *goquery.Selection) {
-
-		// Costrisco l'intero url
-		idString, idBool := s.Attr("href")
-		if idBool {
-			id := getIDFromString(idString)
-			// log.Println(rootURL.Host + "/?ent=avvisoin&cs=" + strconv.Itoa(id))
-			res = append(res, rootURL.Host+"/?ent=avvisoin&cs="+strconv.Itoa(id))
-		}
-	})
-
-	return res, nil
+	return newsPageURLs(degrees, profile.SkipDiscontinued), nil
 }
 
 // NewsPageLinksFromURLCorsoMedicina retrive information from a url based on "medicina" url.
 func NewsPageLinksFromURLCorsoMedicina(urlString string) ([]string, error) {
-	var res []string
-
-	urlString = "http://" + urlString
+	return DefaultClient.NewsPageLinksFromURLCorsoMedicina(urlString)
+}
 
-	rootURL, err := url.Parse(urlString)
-	if err != nil {
-		return nil, err
-	}
+// NewsPageLinksFromURLCorsoMedicina is like the package level
+// NewsPageLinksFromURLCorsoMedicina but uses c for the requests
+func (c *Client) NewsPageLinksFromURLCorsoMedicina(urlString string) ([]string, error) {
+	return c.NewsPageLinksFromURLCorsoMedicinaContext(context.Background(), urlString)
+}
 
-	doc, err := goquery.NewDocument(urlString)
+// NewsPageLinksFromURLCorsoMedicinaContext is like
+// NewsPageLinksFromURLCorsoMedicina but stops as soon as ctx is done
+func (c *Client) NewsPageLinksFromURLCorsoMedicinaContext(ctx context.Context, urlString string) ([]string, error) {
+	return c.newsPageLinksFromURLCorsoMedicina(ctx, urlString, nil)
+}
 
+func (c *Client) newsPageLinksFromURLCorsoMedicina(ctx context.Context, urlString string, warnings *[]Warning) ([]string, error) {
+	profile := MedicinaProfile.withDefaults()
+	degrees, err := c.courseList(ctx, urlString, profile, warnings)
 	if err != nil {
 		return nil, err
 	}
-	doc.Find("#centroservizi").Find("dl").Find("dt").Find("a").Each(func(i int, s *goquery.Selection) {
-		// Start OLD SITE
-		tokens := strings.Split(s.Text(), "(")
-		var stringToTest string
+	// Elimino tutti i corsi che non sono piu' validi
+	return newsPageURLs(degrees, profile.SkipDiscontinued), nil
+}
 
-		if len(tokens) > 1 {
-			stringToTest = tokens[1]
-		} else {
-			stringToTest = tokens[0]
-		}
-		// Elimino tutti i corsi che non sono piu' validi
-		if !strings.Contains(stringToTest, "until") {
-			// Recupero l'ID
-			idString, idBool := s.Attr("href")
-			if idBool {
-				id := getIDFromString(idString)
+// RetriveLast5NewsIDsFromNewsPage retrives last 5 news ids from a news page
+func RetriveLast5NewsIDsFromNewsPage(newsPageURL string) ([]int, error) {
+	return DefaultClient.RetriveLast5NewsIDsFromNewsPage(newsPageURL)
+}
 
-				//log.Println(rootURL.Host + "/?ent=avvisoin&cs=" + strconv.Itoa(id))
-				res = append(res, rootURL.Host+"/?ent=avvisoin&cs="+strconv.Itoa(id))
-			}
-		}
-		// END OLD SITE
-	})
+// RetriveLast5NewsIDsFromNewsPage is like the package level
+// RetriveLast5NewsIDsFromNewsPage but uses c for the requests
+func (c *Client) RetriveLast5NewsIDsFromNewsPage(newsPageURL string) ([]int, error) {
+	return c.RetriveLast5NewsIDsFromNewsPageContext(context.Background(), newsPageURL)
+}
 
-	return res, nil
+// RetriveLast5NewsIDsFromNewsPageContext is like
+// RetriveLast5NewsIDsFromNewsPage but stops as soon as ctx is done
+func (c *Client) RetriveLast5NewsIDsFromNewsPageContext(ctx context.Context, newsPageURL string) ([]int, error) {
+	return c.retriveLast5NewsIDs(ctx, newsPageURL, nil)
 }
 
-// RetriveLast5NewsIDsFromNewsPage retrives last 5 news ids from a news page
-func RetriveLast5NewsIDsFromNewsPage(newsPageURL string) ([]int, error) {
+func (c *Client) retriveLast5NewsIDs(ctx context.Context, newsPageURL string, warnings *[]Warning) ([]int, error) {
 	var res []int
 
+	profile := c.ProfileFor(strings.SplitN(newsPageURL, "/", 2)[0])
 	newsPageURL = "http://" + newsPageURL
 
-	doc, err := goquery.NewDocument(newsPageURL)
+	doc, err := c.document(ctx, newsPageURL)
 	if err != nil {
 		return nil, err
 	}
-	if doc.Find("table").Find("tbody").Find("tr").Find("a").Size() > 5 {
-		doc.Find("table").Find("tbody").Find("tr").Find("a").Slice(0, 5).Each(func(i int, s *goquery.Selection) {
+	if doc.Find(profile.NewsList).Length() == 0 {
+		return nil, layoutChanged(newsPageURL, profile.NewsList)
+	}
+	if doc.Find(profile.NewsRows).Find("a").Size() > 5 {
+		doc.Find(profile.NewsRows).Find("a").Slice(0, 5).Each(func(i int, s *goquery.Selection) {
 			idString, idBool := s.Attr("href")
 			if idBool {
-				id := getIDFromString(idString)
-				// log.Println(rootURL.Host + "/?ent=avvisoin&cs=" + strconv.Itoa(id))
+				id, ok := getIDFromString(idString)
+				if !ok {
+					addWarning(warnings, Warning{Field: "degree_ids", Message: "news link without id skipped", Raw: idString})
+					return
+				}
 				res = append(res, id)
 			}
 		})
 	} else {
-		doc.Find("table").Find("tbody").Find("tr").Find("a").Each(func(i int, s *goquery.Selection) {
+		doc.Find(profile.NewsRows).Find("a").Each(func(i int, s *goquery.Selection) {
 			idString, idBool := s.Attr("href")
 			if idBool {
-				id := getIDFromString(idString)
-				// log.Println(rootURL.Host + "/?ent=avvisoin&cs=" + strconv.Itoa(id))
+				id, ok := getIDFromString(idString)
+				if !ok {
+					addWarning(warnings, Warning{Field: "degree_ids", Message: "news link without id skipped", Raw: idString})
+					return
+				}
 				res = append(res, id)
 			}
 		})
@@ -440,44 +526,106 @@ func RetriveLast5NewsIDsFromNewsPage(newsPageURL string) ([]int, error) {
 	return res, nil
 }
 
-// GetIDFromString retrive int ID from a string like this /?ent=avvisoin&id=432
-func getIDFromString(urlString string) int {
-	var id int
-	// Costruisco l'url completo alla pagina
-	singleURL, _ := url.Parse("www.example.com" + urlString)
+// RetriveAllNewsIDsFromNewsPage returns the IDs of all the news listed by a
+// degree news page, following the pages of the list
+func RetriveAllNewsIDsFromNewsPage(newsPageURL string) ([]int, error) {
+	return DefaultClient.RetriveAllNewsIDsFromNewsPage(newsPageURL)
+}
 
-	// recupero l'ID del corso
-	m, _ := url.ParseQuery(singleURL.RawQuery)
-	if val, ok := m["id"]; ok {
-		id, _ = strconv.Atoi(val[0])
-	} else if val, ok := m["cs"]; ok {
-		id, _ = strconv.Atoi(val[0])
-	} else {
-		log.Warnln(urlString)
-		id = 0
+// RetriveAllNewsIDsFromNewsPage is like the package level
+// RetriveAllNewsIDsFromNewsPage but uses c for the requests and stops at
+// c.HistoryPages and c.HistorySince
+func (c *Client) RetriveAllNewsIDsFromNewsPage(newsPageURL string) ([]int, error) {
+	return c.RetriveAllNewsIDsFromNewsPageContext(context.Background(), newsPageURL)
+}
+
+// RetriveAllNewsIDsFromNewsPageContext is like RetriveAllNewsIDsFromNewsPage
+// but stops as soon as ctx is done
+func (c *Client) RetriveAllNewsIDsFromNewsPageContext(ctx context.Context, newsPageURL string) ([]int, error) {
+	return c.retriveAllNewsIDs(ctx, newsPageURL, nil)
+}
+
+func (c *Client) retriveAllNewsIDs(ctx context.Context, newsPageURL string, warnings *[]Warning) ([]int, error) {
+	var res []int
+
+	profile := c.ProfileFor(strings.SplitN(newsPageURL, "/", 2)[0])
+	pageURL := "http://" + newsPageURL
+	seen := map[string]bool{}
+	for page := 1; !seen[pageURL]; page++ {
+		seen[pageURL] = true
+		doc, err := c.document(ctx, pageURL)
+		if err != nil {
+			return nil, err
+		}
+		if doc.Find(profile.NewsList).Length() == 0 {
+			return nil, layoutChanged(pageURL, profile.NewsList)
+		}
+
+		rows := doc.Find(profile.NewsRows)
+		old := 0
+		rows.Each(func(i int, s *goquery.Selection) {
+			// La data e' nella prima colonna, gg/mm/aaaa
+			if !c.HistorySince.IsZero() {
+				date, err := time.Parse("02/01/2006", strings.TrimSpace(s.Find("td").First().Text()))
+				if err == nil && !date.AddDate(0, 0, 1).After(c.HistorySince) {
+					old++
+					return
+				}
+			}
+			idString, idBool := s.Find("a").First().Attr("href")
+			if idBool {
+				id, ok := getIDFromString(idString)
+				if !ok {
+					addWarning(warnings, Warning{Field: "degree_ids", Message: "news link without id skipped", Raw: idString})
+					return
+				}
+				res = append(res, id)
+			}
+		})
+
+		if old > 0 && old == rows.Length() || c.HistoryPages > 0 && page >= c.HistoryPages {
+			break
+		}
+		next, ok := doc.Find(profile.NewsList).Find(profile.NextPage).Attr("href")
+		if !ok {
+			break
+		}
+		nextURL, err := doc.Url.Parse(next)
+		if err != nil {
+			addWarning(warnings, Warning{Field: "degree_ids", Message: "invalid link to the next page", Raw: next})
+			break
+		}
+		pageURL = nextURL.String()
 	}
 
-	return id
+	return res, nil
 }
 
-// GetIDFromString retrive int ID from a string like this www.univr.it/?ent=avvisoin&id=432
-func getIDFromCompleteURL(urlString string) int {
-	var id int
+// GetIDFromString retrive int ID from a string like this /?ent=avvisoin&id=432.
+// It returns false if the string has neither an id nor a cs parameter.
+func getIDFromString(urlString string) (int, bool) {
 	// Costruisco l'url completo alla pagina
-	singleURL, _ := url.Parse(urlString)
+	return getIDFromCompleteURL("www.example.com" + urlString)
+}
+
+// GetIDFromString retrive int ID from a string like this www.univr.it/?ent=avvisoin&id=432
+func getIDFromCompleteURL(urlString string) (int, bool) {
+	singleURL, err := url.Parse(urlString)
+	if err != nil {
+		return 0, false
+	}
 
 	// recupero l'ID del corso
 	m, _ := url.ParseQuery(singleURL.RawQuery)
-	if val, ok := m["id"]; ok {
-		id, _ = strconv.Atoi(val[0])
-	} else if val, ok := m["cs"]; ok {
-		id, _ = strconv.Atoi(val[0])
-	} else {
-		log.Warnln(urlString)
-		id = 0
+	val, ok := m["id"]
+	if !ok {
+		val, ok = m["cs"]
 	}
-
-	return id
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.Atoi(val[0])
+	return id, err == nil
 }
 
 // =============================================================================
@@ -502,6 +650,38 @@ func HtmlBRDivisorTOArray(html string) (res []string) {
 	return res
 }
 
+// brLines returns the text of the children of nodes split at the <br>
+// elements, without the empty lines and the content of the dropped elements
+func brLines(nodes []*html.Node) []string {
+	var res []string
+	var line strings.Builder
+	flush := func() {
+		if text := removeExtraSpaces(line.String()); text != "" {
+			res = append(res, text)
+		}
+		line.Reset()
+	}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			switch {
+			case child.Type == html.TextNode:
+				line.WriteString(child.Data)
+			case child.Type != html.ElementNode || containsString(droppedElements, child.Data):
+			case child.Data == "br":
+				flush()
+			default:
+				walk(child)
+			}
+		}
+	}
+	for _, n := range nodes {
+		walk(n)
+	}
+	flush()
+	return res
+}
+
 func removeExtraSpaces(s string) string {
 	ReLeadCloseWhtsp := regexp.MustCompile(`^[\s\p{Zs}]+|[\s\p{Zs}]+$`)
 	ReInsideWhtsp := regexp.MustCompile(`[\s\p{Zs}]{2,}`)
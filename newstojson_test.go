@@ -1,6 +1,7 @@
 package newstojson
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -14,24 +15,20 @@ import (
 	rss "github.com/jteeuwen/go-pkg-rss"
 )
 
-// News container
-type NewsCustom struct {
-	Title       string
-	Description string
-	Content     string
-	Link        *url.URL
-	PubTime     time.Time // Pubblication time
-}
-
 func TestParse(t *testing.T) {
 	activationTime := time.Now()
+	c := newFixtureClient(t)
 
 	content, _ := ioutil.ReadFile("testdata/data.rss")
 	feed := rss.New(1, true, chanTestHandler, func(feed *rss.Feed, ch *rss.Channel, newitems []*rss.Item) {
 		log.Println("Parsing all items...")
 		for _, item := range newitems {
-			newitem, err := Parse(item)
-			newitem.CompleteParse()
+			newitem, err := c.Parse(item)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+			err = c.CompleteParse(newitem)
 			if err != nil {
 				t.Error(err)
 			}
@@ -50,15 +47,11 @@ func TestParse(t *testing.T) {
 			PrintAttachments(newitem.Attachments)
 			log.Println("Content      : " + newitem.Content)
 
-			n := NewsCustom{
-				Title:       newitem.Title,
-				Description: newitem.Description,
-				Content:     newitem.Content,
-				Link:        newitem.Link,
-				PubTime:     newitem.PubTime,
-			}
 			log.Println("Printing JSON...")
-			res1B, _ := json.Marshal(n)
+			res1B, err := json.Marshal(newitem)
+			if err != nil {
+				t.Error(err)
+			}
 			fmt.Println(string(res1B))
 
 		}
@@ -67,7 +60,32 @@ func TestParse(t *testing.T) {
 	feed.FetchBytes("http://example.com", content, nil)
 }
 
+func TestNewsFromItemWarnings(t *testing.T) {
+	guid := "http://www.di.univr.it/?ent=avviso&id=119016"
+	item, err := newsFromItem(&rss.Item{Title: "Exam results", PubDate: "Mon, 31 Oct 2016 10:26:32 +0100", Guid: &guid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.ID != 119016 || item.Link.String() != guid {
+		t.Error("Expected the link and ID of the guid, got", item.Link, item.ID)
+	}
+	expected := []Warning{{Field: "link", Message: "link taken from guid", Raw: guid}}
+	if !reflect.DeepEqual(item.Warnings, expected) {
+		t.Errorf("Expected %v, got %v", expected, item.Warnings)
+	}
+
+	item, err = newsFromItem(&rss.Item{PubDate: "Mon, 31 Oct 2016 10:26:32 +0100", Links: []*rss.Link{{Href: "http://www.di.univr.it/"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []Warning{{Field: "id", Message: "no ID in link and guid", Raw: "http://www.di.univr.it/"}}
+	if !reflect.DeepEqual(item.Warnings, expected) {
+		t.Errorf("Expected %v, got %v", expected, item.Warnings)
+	}
+}
+
 func TestNewsPageLinksFromURLCorso(t *testing.T) {
+	c := newFixtureClient(t)
 	url := "www.dbt.univr.it/?ent=cs&tcs=N"
 
 	correctUrls := []string{
@@ -76,7 +94,7 @@ func TestNewsPageLinksFromURLCorso(t *testing.T) {
 		"www.dbt.univr.it/?ent=avvisoin&cs=419",
 	}
 
-	res, err := NewsPageLinksFromURLCorso(url)
+	res, err := c.NewsPageLinksFromURLCorso(url)
 	if err != nil {
 		t.Error(err)
 	}
@@ -89,46 +107,107 @@ func TestNewsPageLinksFromURLCorso(t *testing.T) {
 	}
 
 	url = "**()(&**(&*(^&*("
-	_, err = NewsPageLinksFromURLCorso(url)
+	_, err = c.NewsPageLinksFromURLCorso(url)
 	if err == nil {
 		t.Error("Expected error but got nil")
 	}
 }
 
 func TestRetriveLast5NewsIDsFromNewsPage(t *testing.T) {
-	res, err := RetriveLast5NewsIDsFromNewsPage("www.di.univr.it/?ent=avvisoin&cs=417")
+	c := newFixtureClient(t)
+	res, err := c.RetriveLast5NewsIDsFromNewsPage("www.di.univr.it/?ent=avvisoin&cs=417")
 	if err != nil {
 		t.Error(err)
 	}
-	if len(res) != 5 {
-		t.Error("Expected 5 elements, got", len(res))
+	expected := []int{130134, 119016, 130001, 130002, 130003}
+	if !reflect.DeepEqual(expected, res) {
+		t.Error("Expected", expected, "got", res)
+	}
+
+	res, err = c.RetriveLast5NewsIDsFromNewsPage("www.di.univr.it/?ent=avvisoin&cs=385")
+	if err != nil {
+		t.Error(err)
+	}
+	expected = []int{118037, 119084}
+	if !reflect.DeepEqual(expected, res) {
+		t.Error("Expected", expected, "got", res)
+	}
+}
+
+func TestRetriveAllNewsIDsFromNewsPage(t *testing.T) {
+	var tests = []struct {
+		pages    int
+		since    time.Time
+		expected []int
+	}{
+		{0, time.Time{}, []int{123492, 130001, 130002, 130003, 130004, 130005, 118991, 119084, 118900, 118037}},
+		{2, time.Time{}, []int{123492, 130001, 130002, 130003, 130004, 130005, 118991, 119084, 118900}},
+		{0, time.Date(2016, 10, 25, 0, 0, 0, 0, time.UTC), []int{123492, 130001, 130002, 130003, 130004, 130005, 118991, 119084}},
+	}
+
+	for _, tt := range tests {
+		c := newFixtureClient(t)
+		c.HistoryPages = tt.pages
+		c.HistorySince = tt.since
+		res, err := c.RetriveAllNewsIDsFromNewsPage("www.di.univr.it/?ent=avvisoin&cs=420")
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !reflect.DeepEqual(tt.expected, res) {
+			t.Errorf("pages %d, since %v: expected %v, got %v", tt.pages, tt.since, tt.expected, res)
+		}
+	}
+
+	// The five most recent notices do not include 118991
+	c := newFixtureClient(t)
+	c.History = true
+	link, _ := url.Parse("http://www.di.univr.it/?ent=avviso&dest=&id=118991")
+	item := newsFromLink(link)
+	err := c.SetIDsCourses(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(item.DegreeIds, []int{420}) {
+		t.Error("Expected [420], got", item.DegreeIds)
 	}
 }
 
 func TestGetNewsPagesFromHost(t *testing.T) {
-	res, err := getNewsPagesFromHost("www.di.univr.it")
+	c := newFixtureClient(t)
+	res, err := c.getNewsPagesFromHost(context.Background(), "www.di.univr.it", nil)
 	if err != nil {
 		t.Error(err)
 	}
-	// Expected:
-	// www.di.univr.it/?ent=avvisoin&cs=419
-	// www.di.univr.it/?ent=avvisoin&cs=420
-	// www.di.univr.it/?ent=avvisoin&cs=418
-	// www.di.univr.it/?ent=avvisoin&cs=385
-	// www.di.univr.it/?ent=avvisoin&cs=417
-	// www.di.univr.it/?ent=avvisoin&cs=389
-	// www.di.univr.it/?ent=avvisoin&cs=769
-	// www.di.univr.it/?ent=avvisoin&cs=792
-	if len(res) != 8 {
-		t.Error("Expected 8 elements, got", len(res))
+	expected := []string{
+		"www.di.univr.it/?ent=avvisoin&cs=419",
+		"www.di.univr.it/?ent=avvisoin&cs=420",
+		"www.di.univr.it/?ent=avvisoin&cs=418",
+		"www.di.univr.it/?ent=avvisoin&cs=385",
+		"www.di.univr.it/?ent=avvisoin&cs=417",
+		"www.di.univr.it/?ent=avvisoin&cs=389",
+		"www.di.univr.it/?ent=avvisoin&cs=769",
+		"www.di.univr.it/?ent=avvisoin&cs=792",
+	}
+	if !reflect.DeepEqual(expected, res) {
+		t.Error("Expected", expected, "got", res)
 	}
 
-	res, err = getNewsPagesFromHostMedicina("www.medicina.univr.it")
+	// Courses no longer active ("until ...") are skipped
+	res, err = c.getNewsPagesFromHost(context.Background(), "www.medicina.univr.it", nil)
 	if err != nil {
 		t.Error(err)
 	}
-	if len(res) != 22 {
-		t.Error("Expected 22 elements, got", len(res))
+	expected = []string{
+		"www.medicina.univr.it/?ent=avvisoin&cs=101",
+		"www.medicina.univr.it/?ent=avvisoin&cs=102",
+		"www.medicina.univr.it/?ent=avvisoin&cs=103",
+		"www.medicina.univr.it/?ent=avvisoin&cs=104",
+		"www.medicina.univr.it/?ent=avvisoin&cs=105",
+		"www.medicina.univr.it/?ent=avvisoin&cs=106",
+	}
+	if !reflect.DeepEqual(expected, res) {
+		t.Error("Expected", expected, "got", res)
 	}
 }
 
@@ -175,7 +254,7 @@ func chanTestHandler(feed *rss.Feed, newchannels []*rss.Channel) {
 }
 
 func TestParseFromLink(t *testing.T) {
-	// activationTime := time.Now()
+	c := newFixtureClient(t)
 
 	var tests = []struct {
 		url string // input
@@ -183,20 +262,22 @@ func TestParseFromLink(t *testing.T) {
 		id          int
 		modtime     bool
 		attachments int
+		degrees     []int
 	}{
-		{"http://www.di.univr.it/?ent=avviso&dest=&id=119016&lang=eng", 119016, false, 0},
-		{"http://www.di.univr.it/?dest=&ent=avviso&id=123492&lang=eng", 123492, false, 0},
-		{"http://www.di.univr.it/?ent=avviso&dest=&id=118991&lang=eng", 118991, true, 0},
-		{"http://www.medicina.univr.it/fol/?ent=avviso&dest=25&id=119149", 119149, true, 1},
-		{"http://www.di.univr.it/?dest=&ent=avviso&id=130134&lang=eng", 130134, true, 0},
+		{"http://www.di.univr.it/?ent=avviso&dest=&id=119016&lang=eng", 119016, false, 0, []int{419, 417}},
+		{"http://www.di.univr.it/?dest=&ent=avviso&id=123492&lang=eng", 123492, false, 0, []int{420, 769}},
+		{"http://www.di.univr.it/?ent=avviso&dest=&id=118991&lang=eng", 118991, true, 0, nil},
+		{"http://www.medicina.univr.it/fol/?ent=avviso&dest=25&id=119149", 119149, true, 1, []int{101, 105}},
+		{"http://www.di.univr.it/?dest=&ent=avviso&id=130134&lang=eng", 130134, true, 0, []int{419, 417}},
 	}
 	for _, tt := range tests {
 		tmp, _ := url.Parse(tt.url)
-		newitem, err := ParseFromLink(tmp)
+		newitem, err := c.ParseFromLink(tmp)
 		if err != nil {
 			t.Error(err)
+			continue
 		}
-		err = newitem.SetIDsCourses()
+		err = c.SetIDsCourses(newitem)
 		if err != nil {
 			t.Error(err)
 		}
@@ -221,8 +302,103 @@ func TestParseFromLink(t *testing.T) {
 		if len(newitem.Attachments) != tt.attachments {
 			t.Errorf("attachments(%s): expected %d attachments, actual %d", tt.url, tt.attachments, len(newitem.Attachments))
 		}
+		for _, attach := range newitem.Attachments {
+			if attach.Preview == "" {
+				t.Errorf("attachments(%s): expected a preview for %s", tt.url, attach.Link)
+			}
+		}
+		if !reflect.DeepEqual(newitem.DegreeIds, tt.degrees) {
+			t.Errorf("degrees(%s): expected %v, actual %v", tt.url, tt.degrees, newitem.DegreeIds)
+		}
+	}
+}
+
+func TestParseFromLinkItalian(t *testing.T) {
+	c := newFixtureClient(t)
+	c.Language = "ita"
 
-		// log.Println(newitem.Content)
+	link, _ := url.Parse("http://www.di.univr.it/?ent=avviso&dest=&id=119016&lang=eng")
+	item, err := c.ParseFromLink(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loc, _ := time.LoadLocation("Europe/Rome")
+	if item.Link.Query().Get("lang") != "ita" {
+		t.Error("Expected lang=ita, got", item.Link)
+	}
+	if item.Title != "Esiti esame - Algoritmi" {
+		t.Error("Unexpected title", item.Title)
+	}
+	if !item.PubTime.Equal(time.Date(2016, 10, 31, 10, 26, 32, 0, loc)) {
+		t.Error("Unexpected pub time", item.PubTime)
+	}
+	if !item.ModTime.Equal(time.Date(2016, 11, 2, 9, 12, 5, 0, loc)) {
+		t.Error("Unexpected mod time", item.ModTime)
+	}
+	if item.Author != "Roberto Segala" || !reflect.DeepEqual(item.Courses, []string{"Algoritmi (2016/2017)"}) {
+		t.Error("Unexpected author and courses", item.Author, item.Courses)
+	}
+}
+
+func TestTranslations(t *testing.T) {
+	c := newFixtureClient(t)
+	c.Languages = []string{"eng", "ita"}
+
+	link, _ := url.Parse("http://www.di.univr.it/?ent=avviso&dest=&id=119016&lang=eng")
+	item, err := c.ParseFromLink(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(item.Translations) != 2 {
+		t.Fatal("Expected two translations, got", item.Translations)
+	}
+	eng, ita := item.Translations["eng"], item.Translations["ita"]
+	if eng.Title != item.Title || eng.Untranslated {
+		t.Error("Unexpected English text", eng)
+	}
+	if ita.Title != "Esiti esame - Algoritmi" || ita.Untranslated {
+		t.Error("Unexpected Italian text", ita)
+	}
+
+	// The English page of 118037 repeats the Italian text
+	link, _ = url.Parse("http://www.di.univr.it/?ent=avviso&dest=165&rss=0&id=118037")
+	item, err = c.ParseFromLink(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !item.Translations["eng"].Untranslated || item.Translations["ita"].Untranslated {
+		t.Error("Expected only the English text marked untranslated", item.Translations)
+	}
+
+	b, err := json.Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded News
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded.Translations, item.Translations) {
+		t.Errorf("Expected %v after a round trip, got %v", item.Translations, decoded.Translations)
+	}
+}
+
+func TestEnglishDate(t *testing.T) {
+	ita := UnivrProfile.Languages["ita"]
+	var tests = []struct {
+		value    string
+		expected string
+	}{
+		{"lunedì31ottobre2016-10:26:32", "Monday31October2016-10:26:32"},
+		{"Mercoledì2Novembre2016-09:12:05", "Wednesday2November2016-09:12:05"},
+		{"Monday,October31,2016-10:26:32AM", "Monday,October31,2016-10:26:32AM"},
+		{"martedì1mar.2016-9:00", "Tuesday1Mar2016-9:00"},
+	}
+	for _, tt := range tests {
+		if res := ita.englishDate(tt.value); res != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.value, tt.expected, res)
+		}
 	}
 }
 
@@ -238,3 +414,172 @@ func TestSetIDFromURL(t *testing.T) {
 		t.Error("Expected 101 but got", item.ID)
 	}
 }
+
+const testNewsPage = `<html><body>
+<h1>Exam results</h1>
+<div class="main-text"><p>The   results of the <b>exam</b> are available.</p></div>
+<dl id="dettagliAvviso">
+	<dt>Publication date</dt>
+	<dd>Monday, October 31, 2016 - 10:26:32 AM</dd>
+	<dt>Last Modified</dt>
+	<dd>Tuesday, November 1, 2016 - 3:05:00 PM</dd>
+	<dt>Published by</dt>
+	<dd>Mario Rossi<br/>Algorithms (2016/2017)<br/>Databases (2016/2017)</dd>
+</dl>
+<ul class="formati">
+	<li><a href="/documenti/Avviso/all/all123.pdf" onclick="anteprima('/documenti/Avviso/all/all123.pdf','/?ent=avviso&amp;id=119016&amp;preview=1')">Results [pdf, 10 KB]</a></li>
+</ul>
+</body></html>`
+
+func TestParseHTML(t *testing.T) {
+	link, _ := url.Parse("http://www.di.univr.it/?ent=avviso&dest=&id=119016&lang=eng")
+	item, err := ParseHTML(strings.NewReader(testNewsPage), link)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loc, _ := time.LoadLocation("Europe/Rome")
+	if item.ID != 119016 {
+		t.Error("Expected ID 119016, got", item.ID)
+	}
+	if item.Title != "Exam results" {
+		t.Error("Unexpected title", item.Title)
+	}
+	if !strings.Contains(item.Content, "The results of the exam are available.") {
+		t.Errorf("Unexpected content %q", item.Content)
+	}
+	if !item.PubTime.Equal(time.Date(2016, 10, 31, 10, 26, 32, 0, loc)) {
+		t.Error("Unexpected pub time", item.PubTime)
+	}
+	if !item.ModTime.Equal(time.Date(2016, 11, 1, 15, 5, 0, 0, loc)) {
+		t.Error("Unexpected mod time", item.ModTime)
+	}
+	if item.Author != "Mario Rossi" {
+		t.Error("Unexpected author", item.Author)
+	}
+	if !reflect.DeepEqual(item.Courses, []string{"Algorithms (2016/2017)", "Databases (2016/2017)"}) {
+		t.Error("Unexpected courses", item.Courses)
+	}
+	if len(item.Attachments) != 1 {
+		t.Fatal("Expected 1 attachment, got", len(item.Attachments))
+	}
+	attach := item.Attachments[0]
+	if attach.Link != "http://www.di.univr.it/?ent=avviso&id=119016&preview=1" {
+		t.Error("Unexpected attachment link", attach.Link)
+	}
+	if attach.Preview != "" {
+		t.Error("Expected no preview, got", attach.Preview)
+	}
+}
+
+func TestParseHTMLAuthorSanitized(t *testing.T) {
+	link, _ := url.Parse("http://www.di.univr.it/?ent=avviso&id=119016")
+	page := strings.Replace(testNewsPage, "<dd>Mario Rossi<br/>Algorithms (2016/2017)<br/>Databases (2016/2017)</dd>",
+		`<dd>Mario <b onmouseover="alert(1)">O&#39;Rossi</b><br/><a href="javascript:alert(1)">Algorithms</a> &amp; more<script>alert(1)</script><br/></dd>`, 1)
+	item, err := ParseHTML(strings.NewReader(page), link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Author != "Mario O'Rossi" {
+		t.Errorf("Unexpected author %q", item.Author)
+	}
+	if !reflect.DeepEqual(item.Courses, []string{"Algorithms & more"}) {
+		t.Errorf("Unexpected courses %q", item.Courses)
+	}
+}
+
+func TestParseHTMLPlainText(t *testing.T) {
+	link, _ := url.Parse("http://www.di.univr.it/?ent=avviso&id=119016")
+	page := strings.Replace(testNewsPage, "<p>The   results of the <b>exam</b> are available.</p>",
+		"<p>Write   &lt;b&gt; for bold &amp; &lt;i&gt; for italic</p>", 1)
+	page = strings.Replace(page, "Results [pdf, 10 KB]", "Results  &lt;draft&gt; [pdf, 10 KB]", 1)
+	item, err := ParseHTML(strings.NewReader(page), link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Content != "Write <b> for bold & <i> for italic" {
+		t.Errorf("Unexpected content %q", item.Content)
+	}
+	if len(item.Attachments) != 1 || item.Attachments[0].Title != "Results <draft> [pdf, 10 KB]" {
+		t.Error("Unexpected attachments", item.Attachments)
+	}
+}
+
+func TestParseHTMLNilLink(t *testing.T) {
+	if _, err := ParseHTML(strings.NewReader(testNewsPage), nil); err == nil {
+		t.Error("Expected an error for a nil link")
+	}
+}
+
+func TestParseHTMLOnclick(t *testing.T) {
+	link, _ := url.Parse("http://www.di.univr.it/?ent=avviso&id=119016")
+	page := strings.Replace(testNewsPage, `onclick="anteprima(`, `onclick="window.open(this.href)" data-x="`, 1)
+	item, err := ParseHTML(strings.NewReader(page), link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(item.Attachments) != 1 || item.Attachments[0].Link != "http://www.di.univr.it/documenti/Avviso/all/all123.pdf" {
+		t.Error("Unexpected attachments", item.Attachments)
+	}
+	if len(item.Warnings) != 1 || item.Warnings[0].Field != "attachments" {
+		t.Error("Expected a warning for the onclick, got", item.Warnings)
+	}
+}
+
+func TestParseHTMLAttachments(t *testing.T) {
+	link, _ := url.Parse("http://www.di.univr.it/?ent=avviso&id=119016")
+	page := strings.Replace(testNewsPage, "</ul>", `<li><a href="/documenti/all124.pdf">Notes</a></li><li>No link</li></ul>`, 1)
+	item, err := ParseHTML(strings.NewReader(page), link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Attachment{
+		{Title: "Results [pdf, 10 KB]", Link: "http://www.di.univr.it/?ent=avviso&id=119016&preview=1"},
+		{Title: "Notes", Link: "http://www.di.univr.it/documenti/all124.pdf"},
+		{Title: "No link"},
+	}
+	if !reflect.DeepEqual(item.Attachments, expected) {
+		t.Errorf("Expected %v, got %v", expected, item.Attachments)
+	}
+}
+
+func TestContentHTML(t *testing.T) {
+	c := newFixtureClient(t)
+	link, _ := url.Parse("http://www.di.univr.it/?ent=avviso&dest=&id=119016&lang=eng")
+	item, err := c.ParseFromLink(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<p>The results of the <strong>Algorithms</strong> written exam of 24 October 2016 are available on <a href="https://esse3.univr.it">ESSE3</a>.</p> ` +
+		`<p>Students can view their papers on Thursday 3 November at 14:30 in room <em>Ca&#39; Vignal 2 - M</em>.</p>`
+	if item.ContentHTML != expected {
+		t.Errorf("Expected %s, got %s", expected, item.ContentHTML)
+	}
+}
+
+func TestContentMarkdown(t *testing.T) {
+	c := newFixtureClient(t)
+	link, _ := url.Parse("http://www.di.univr.it/?ent=avviso&dest=&id=119016&lang=eng")
+	item, err := c.ParseFromLink(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "The results of the **Algorithms** written exam of 24 October 2016 are available on [ESSE3](https://esse3.univr.it).\n\n" +
+		"Students can view their papers on Thursday 3 November at 14:30 in room *Ca' Vignal 2 - M*."
+	if item.ContentMarkdown != expected {
+		t.Errorf("Expected %q, got %q", expected, item.ContentMarkdown)
+	}
+}
+
+func TestLinks(t *testing.T) {
+	c := newFixtureClient(t)
+	link, _ := url.Parse("http://www.di.univr.it/?ent=avviso&dest=&id=119016&lang=eng")
+	item, err := c.ParseFromLink(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Link{{Text: "ESSE3", URL: "https://esse3.univr.it", Kind: LinkInternal}}
+	if !reflect.DeepEqual(item.Links, expected) {
+		t.Errorf("Expected %v, got %v", expected, item.Links)
+	}
+}
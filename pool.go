@@ -0,0 +1,108 @@
+package newstojson
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+func (c *Client) concurrency() int {
+	if c == nil || c.Concurrency < 1 {
+		return 1
+	}
+	return c.Concurrency
+}
+
+// forEach calls fn for every index in [0, n) using at most c.Concurrency
+// goroutines. It stops at the first error, cancelling the context passed to
+// the calls still running, and returns the error of the lowest index.
+func (c *Client) forEach(ctx context.Context, n int, fn func(ctx context.Context, i int) error) error {
+	workers := c.concurrency()
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			err := fn(ctx, i)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make([]error, n)
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				errs[i] = fn(ctx, i)
+				if errs[i] != nil {
+					cancel()
+				}
+			}
+		}()
+	}
+	for i := 0; i < n && ctx.Err() == nil; i++ {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	// Prefer the error that triggered the cancel to the ones it caused
+	var first error
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return err
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	if first != nil {
+		return first
+	}
+	return ctx.Err()
+}
+
+// wait blocks until a request to host respects c.HostInterval, or until ctx
+// is done
+func (c *Client) wait(ctx context.Context, host string) error {
+	if c == nil || c.HostInterval <= 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	if c.next == nil {
+		c.next = make(map[string]time.Time)
+	}
+	at := c.next[host]
+	if now := time.Now(); at.Before(now) {
+		at = now
+	}
+	c.next[host] = at.Add(c.HostInterval)
+	c.mu.Unlock()
+
+	d := time.Until(at)
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
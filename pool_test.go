@@ -0,0 +1,109 @@
+package newstojson
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSetIDsCoursesConcurrent(t *testing.T) {
+	var tests = []struct {
+		link    string
+		degrees []int
+	}{
+		{"http://www.di.univr.it/?ent=avviso&dest=&id=119016", []int{419, 417}},
+		{"http://www.di.univr.it/?ent=avviso&dest=&id=123492", []int{420, 769}},
+		{"http://www.medicina.univr.it/fol/?ent=avviso&dest=25&id=119149", []int{101, 105}},
+	}
+
+	c := newFixtureClient(t)
+	c.Concurrency = 8
+	for _, tt := range tests {
+		link, _ := url.Parse(tt.link)
+		item := newsFromLink(link)
+		err := c.SetIDsCourses(item)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !reflect.DeepEqual(item.DegreeIds, tt.degrees) {
+			t.Errorf("%s: expected %v, got %v", tt.link, tt.degrees, item.DegreeIds)
+		}
+	}
+}
+
+func TestForEach(t *testing.T) {
+	c := &Client{Concurrency: 3}
+
+	var mu sync.Mutex
+	running, max := 0, 0
+	res := make([]int, 20)
+	err := c.forEach(context.Background(), len(res), func(ctx context.Context, i int) error {
+		mu.Lock()
+		running++
+		if running > max {
+			max = running
+		}
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+		res[i] = i * i
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if max > 3 {
+		t.Error("Expected at most 3 calls at the same time, got", max)
+	}
+	for i, v := range res {
+		if v != i*i {
+			t.Errorf("res[%d]: expected %d, got %d", i, i*i, v)
+		}
+	}
+
+	errFail := errors.New("fail")
+	err = c.forEach(context.Background(), 20, func(ctx context.Context, i int) error {
+		if i == 1 {
+			return errFail
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err != errFail {
+		t.Error("Expected the failing call error, got", err)
+	}
+}
+
+func TestHostInterval(t *testing.T) {
+	var mu sync.Mutex
+	var starts []time.Time
+	c := NewClient(FetcherFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		starts = append(starts, time.Now())
+		mu.Unlock()
+		return htmlResponse(req, ""), nil
+	}))
+	c.Concurrency = 4
+	c.HostInterval = 20 * time.Millisecond
+
+	err := c.forEach(context.Background(), 4, func(ctx context.Context, i int) error {
+		_, err := c.readAll(ctx, "http://www.di.univr.it/")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := starts[len(starts)-1].Sub(starts[0]); d < 55*time.Millisecond {
+		t.Error("Expected the requests to be spread over 60ms, got", d)
+	}
+}
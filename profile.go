@@ -0,0 +1,237 @@
+package newstojson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// SiteProfile describes the layout of a department site: where the parser
+// finds the parts of a news page, the labels of its details and how the
+// course and notice lists are laid out. Profiles can be loaded from JSON with
+// LoadProfiles, so that a redesign of a site only needs a new profile.
+type SiteProfile struct {
+	Name string `json:"name"`
+
+	// Hosts are the domains the profile applies to, subdomains included,
+	// like medicina.univr.it
+	Hosts []string `json:"hosts"`
+
+	// News page
+	Title       string   `json:"title,omitempty"`       // Title of the news, like h1
+	Content     []string `json:"content,omitempty"`     // Body of the news, the first one not empty is used
+	Details     string   `json:"details,omitempty"`     // List of dt labels and dd values
+	Attachments string   `json:"attachments,omitempty"` // One element per attachment, holding its link
+
+	// Languages maps the lang parameter of the pages, like eng or ita, to
+	// the labels and dates of the details in that language
+	Languages map[string]PageLanguage `json:"languages,omitempty"`
+
+	// Course lists, /?ent=cs&tcs=<type>
+	CourseList       string `json:"course_list,omitempty"`       // Element holding the list
+	CourseListScope  string `json:"course_list_scope,omitempty"` // First element inside CourseList holding the links, if any
+	CourseLinks      string `json:"course_links,omitempty"`      // Links to the degrees
+	SkipDiscontinued bool   `json:"skip_discontinued,omitempty"` // Leave out the degrees marked "until"
+
+	// Notice lists of a degree, /?ent=avvisoin&cs=<id>
+	NewsList string `json:"news_list,omitempty"` // Element holding the list
+	NewsRows string `json:"news_rows,omitempty"` // One row per notice, date in the first cell
+	NextPage string `json:"next_page,omitempty"` // Link to the next page of the list
+}
+
+// PageLanguage describes the news details of the pages in a language: the
+// texts of their dt elements and the format of their dates
+type PageLanguage struct {
+	PubTime string `json:"pub_time,omitempty"`
+	ModTime string `json:"mod_time,omitempty"`
+	Author  string `json:"author,omitempty"`
+
+	// DateLayout is an extra layout of the dates without spaces, once the
+	// names of Months and Days are replaced by the English ones. It is
+	// tried before the built-in layouts, which cover the univr sites.
+	DateLayout string   `json:"date_layout,omitempty"`
+	Months     []string `json:"months,omitempty"` // January to December, if not English; abbreviations are the first three letters
+	Days       []string `json:"days,omitempty"`   // Sunday to Saturday, if not English
+}
+
+// englishDate replaces the month and weekday names of value, and the
+// abbreviated month names like "ott.", with the English ones understood by
+// time.Parse
+func (l PageLanguage) englishDate(value string) string {
+	var names, abbreviations []string
+	english := map[string]string{}
+	for i, name := range l.Months {
+		month := time.Month(i + 1).String()
+		names = append(names, regexp.QuoteMeta(name))
+		english[strings.ToLower(name)] = month
+		if short := []rune(name); len(short) > 3 {
+			abbreviations = append(abbreviations, regexp.QuoteMeta(string(short[:3]))+`\.?`)
+			english[strings.ToLower(string(short[:3]))] = month[:3]
+		}
+	}
+	for i, name := range l.Days {
+		names = append(names, regexp.QuoteMeta(name))
+		english[strings.ToLower(name)] = time.Weekday(i).String()
+	}
+	if len(names) == 0 {
+		return value
+	}
+	// The full names come first, so that marzo is not read as mar
+	re := regexp.MustCompile("(?i)" + strings.Join(append(names, abbreviations...), "|"))
+	return re.ReplaceAllStringFunc(value, func(name string) string {
+		return english[strings.ToLower(strings.TrimSuffix(name, "."))]
+	})
+}
+
+// UnivrProfile is the layout shared by the department sites of the
+// University of Verona
+var UnivrProfile = SiteProfile{
+	Name:        "univr",
+	Hosts:       []string{"univr.it"},
+	Title:       "h1",
+	Content:     []string{".main-text", ".sezione"},
+	Details:     "#dettagliAvviso",
+	Attachments: ".formati li",
+	Languages: map[string]PageLanguage{
+		"eng": {
+			PubTime: "Publication date",
+			ModTime: "Last Modified",
+			Author:  "Published by",
+		},
+		"ita": {
+			PubTime: "Data pubblicazione",
+			ModTime: "Ultima modifica",
+			Author:  "Pubblicato da",
+			Months: []string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno",
+				"luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+			Days: []string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+		},
+	},
+	CourseList:      "#contenutoPagina",
+	CourseListScope: "div",
+	CourseLinks:     "dl dt a",
+	NewsList:        "#contenutoPagina",
+	NewsRows:        "table tbody tr",
+	NextPage:        "a[rel=next]",
+}
+
+// MedicinaProfile is the layout of the School of Medicine site, whose course
+// lists live in #centroservizi and keep the discontinued degrees
+var MedicinaProfile = SiteProfile{
+	Name:             "medicina",
+	Hosts:            []string{"medicina.univr.it"},
+	CourseList:       "#centroservizi",
+	CourseLinks:      "dl dt a",
+	SkipDiscontinued: true,
+}
+
+var builtinProfiles = []*SiteProfile{&UnivrProfile, &MedicinaProfile}
+
+// LoadProfiles reads a JSON array of profiles. The fields left out of a
+// profile are taken from UnivrProfile.
+func LoadProfiles(r io.Reader) ([]SiteProfile, error) {
+	var res []SiteProfile
+	err := json.NewDecoder(r).Decode(&res)
+	if err != nil {
+		return nil, fmt.Errorf("newstojson: profiles: %w", err)
+	}
+	for i, p := range res {
+		if p.Name == "" {
+			return nil, fmt.Errorf("newstojson: profiles: profile %d has no name", i+1)
+		}
+		if len(p.Hosts) == 0 {
+			return nil, errors.New("newstojson: profiles: profile " + p.Name + " has no hosts")
+		}
+	}
+	return res, nil
+}
+
+// matches returns the length of the domain of p matching host, or -1
+func (p *SiteProfile) matches(host string) int {
+	best := -1
+	for _, domain := range p.Hosts {
+		if (host == domain || strings.HasSuffix(host, "."+domain)) && len(domain) > best {
+			best = len(domain)
+		}
+	}
+	return best
+}
+
+// ProfileFor returns the profile used by c for host: the most specific
+// match among c.Profiles or, if none matches, among the built-in profiles.
+// Hosts that match no profile get UnivrProfile. The empty fields of the
+// profile are filled from UnivrProfile.
+func (c *Client) ProfileFor(host string) SiteProfile {
+	if i := strings.IndexByte(host, ':'); i >= 0 {
+		host = host[:i]
+	}
+
+	var match *SiteProfile
+	best := -1
+	if c != nil {
+		for i := range c.Profiles {
+			if n := c.Profiles[i].matches(host); n > best {
+				match, best = &c.Profiles[i], n
+			}
+		}
+	}
+	if match == nil {
+		for _, p := range builtinProfiles {
+			if n := p.matches(host); n > best {
+				match, best = p, n
+			}
+		}
+	}
+	if match == nil {
+		match = &UnivrProfile
+	}
+	return match.withDefaults()
+}
+
+// withDefaults returns a copy of p with the empty fields taken from
+// UnivrProfile
+func (p SiteProfile) withDefaults() SiteProfile {
+	d := UnivrProfile
+	fill := func(v *string, def string) {
+		if *v == "" {
+			*v = def
+		}
+	}
+	fill(&p.Title, d.Title)
+	if len(p.Content) == 0 {
+		p.Content = d.Content
+	}
+	fill(&p.Details, d.Details)
+	fill(&p.Attachments, d.Attachments)
+	languages := make(map[string]PageLanguage)
+	for lang, l := range d.Languages {
+		languages[lang] = l
+	}
+	for lang, l := range p.Languages {
+		def := d.Languages[lang]
+		fill(&l.PubTime, def.PubTime)
+		fill(&l.ModTime, def.ModTime)
+		fill(&l.Author, def.Author)
+		if len(l.Months) == 0 {
+			l.Months = def.Months
+		}
+		if len(l.Days) == 0 {
+			l.Days = def.Days
+		}
+		languages[lang] = l
+	}
+	p.Languages = languages
+	if p.CourseList == "" {
+		p.CourseList = d.CourseList
+		p.CourseListScope = d.CourseListScope
+	}
+	fill(&p.CourseLinks, d.CourseLinks)
+	fill(&p.NewsList, d.NewsList)
+	fill(&p.NewsRows, d.NewsRows)
+	fill(&p.NextPage, d.NextPage)
+	return p
+}
@@ -0,0 +1,93 @@
+package newstojson
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestProfileFor(t *testing.T) {
+	var tests = []struct {
+		host string
+		name string
+	}{
+		{"www.di.univr.it", "univr"},
+		{"www.medicina.univr.it", "medicina"},
+		{"medicina.univr.it:8080", "medicina"},
+		{"www.example.com", "univr"},
+	}
+	for _, tt := range tests {
+		p := DefaultClient.ProfileFor(tt.host)
+		if p.Name != tt.name {
+			t.Errorf("%s: expected profile %s, got %s", tt.host, tt.name, p.Name)
+		}
+		if p.Details != "#dettagliAvviso" || p.NewsRows != "table tbody tr" {
+			t.Errorf("%s: expected the default selectors, got %+v", tt.host, p)
+		}
+	}
+
+	p := DefaultClient.ProfileFor("www.medicina.univr.it")
+	if p.CourseList != "#centroservizi" || p.CourseListScope != "" || !p.SkipDiscontinued {
+		t.Errorf("Unexpected medicina course list %+v", p)
+	}
+
+	c := &Client{Profiles: []SiteProfile{{Name: "fol", Hosts: []string{"fol.medicina.univr.it"}}}}
+	if name := c.ProfileFor("www.fol.medicina.univr.it").Name; name != "fol" {
+		t.Error("Expected the client profile, got", name)
+	}
+	if name := c.ProfileFor("www.medicina.univr.it").Name; name != "medicina" {
+		t.Error("Expected the built-in profile, got", name)
+	}
+}
+
+func TestLoadProfiles(t *testing.T) {
+	profiles, err := LoadProfiles(strings.NewReader(`[{
+		"name": "redesign",
+		"hosts": ["di.univr.it"],
+		"title": "h2.titolo",
+		"content": [".testo"],
+		"details": "dl.info",
+		"attachments": ".allegati li",
+		"languages": {"eng": {"pub_time": "Published", "author": "Author"}}
+	}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := `<html><body>
+		<h2 class="titolo">Exam results</h2>
+		<div class="testo"><p>The results are available.</p></div>
+		<dl class="info">
+			<dt>Published</dt>
+			<dd>Monday, October 31, 2016 - 10:26:32 AM</dd>
+			<dt>Author</dt>
+			<dd>Mario Rossi<br/>Algorithms (2016/2017)</dd>
+		</dl>
+		<ul class="allegati"><li><a href="/all.pdf">Results</a></li></ul>
+	</body></html>`
+
+	c := &Client{SkipPreviews: true, Profiles: profiles}
+	link, _ := url.Parse("http://www.di.univr.it/?ent=avviso&id=119016")
+	item, err := c.ParseHTMLContext(context.Background(), strings.NewReader(page), link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Title != "Exam results" || item.Content != "The results are available." || item.Author != "Mario Rossi" {
+		t.Errorf("Unexpected news %q %q %q", item.Title, item.Content, item.Author)
+	}
+	if item.PubTime.IsZero() || len(item.Attachments) != 1 {
+		t.Error("Expected pub time and attachment, got", item.PubTime, item.Attachments)
+	}
+
+	_, err = ParseHTML(strings.NewReader(page), link)
+	if err == nil {
+		t.Error("Expected layout error with the built-in profile")
+	}
+
+	for _, data := range []string{`{}`, `[{"hosts": ["di.univr.it"]}]`, `[{"name": "x"}]`} {
+		_, err = LoadProfiles(strings.NewReader(data))
+		if err == nil {
+			t.Errorf("%s: expected error but got nil", data)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package newstojson
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ============================================================================
+// HTML sanitizer
+// ============================================================================
+
+// Policy is an allow-list of the elements and attributes kept in the HTML
+// emitted by the package. The elements that are neither allowed nor dropped
+// are replaced by their content. Whatever the policy, scripts, styles,
+// frames and the page head are dropped, event handler and style attributes
+// are removed and links must use one of the Schemes.
+type Policy struct {
+	Elements map[string][]string // Allowed elements and their attributes
+	Drop     []string            // Elements removed with their content
+	Schemes  []string            // Schemes allowed in the links, like https
+}
+
+// ContentPolicy keeps the formatting of a notice body: paragraphs, emphasis,
+// lists, tables, links and images. It is used for ContentHTML.
+var ContentPolicy = Policy{
+	Elements: map[string][]string{
+		"p": nil, "br": nil, "hr": nil, "blockquote": nil, "pre": nil, "code": nil,
+		"h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+		"b": nil, "strong": nil, "i": nil, "em": nil, "u": nil, "s": nil, "sub": nil, "sup": nil,
+		"ul": nil, "ol": nil, "li": nil, "dl": nil, "dt": nil, "dd": nil,
+		"table": nil, "caption": nil, "thead": nil, "tbody": nil, "tfoot": nil, "tr": nil,
+		"th": {"colspan", "rowspan"}, "td": {"colspan", "rowspan"},
+		"a":   {"href", "title"},
+		"img": {"src", "alt", "title"},
+	},
+	Drop:    []string{"form", "input", "button", "select", "textarea"},
+	Schemes: []string{"http", "https", "mailto"},
+}
+
+// PreviewPolicy keeps the text formatting, lists and tables of the
+// attachment previews, without links and images
+var PreviewPolicy = Policy{
+	Elements: map[string][]string{
+		"p": nil, "br": nil, "hr": nil, "pre": nil,
+		"b": nil, "strong": nil, "i": nil, "em": nil, "u": nil, "sub": nil, "sup": nil,
+		"ul": nil, "ol": nil, "li": nil,
+		"table": nil, "caption": nil, "thead": nil, "tbody": nil, "tfoot": nil, "tr": nil,
+		"th": {"colspan", "rowspan"}, "td": {"colspan", "rowspan"},
+	},
+	Drop: ContentPolicy.Drop,
+}
+
+// TextPolicy keeps only the text, escaped
+var TextPolicy = Policy{Drop: ContentPolicy.Drop}
+
+// droppedElements are dropped by every policy: the active content and what
+// is not part of the page body
+var droppedElements = []string{
+	"script", "style", "iframe", "frame", "frameset", "object", "embed", "applet", "svg", "math",
+	"head", "title", "base", "link", "meta", "noscript", "template",
+}
+
+// urlAttributes hold a link, checked against the schemes of the policy
+var urlAttributes = []string{"href", "src", "cite", "action", "formaction", "background", "poster"}
+
+// voidElements have no content nor end tag
+var voidElements = map[string]bool{"br": true, "hr": true, "img": true}
+
+var spacesRegexp = regexp.MustCompile(`\s+`)
+
+// Sanitize returns the HTML document or fragment src reduced to the
+// elements allowed by p, with the links resolved against base, which may be
+// nil. Runs of spaces are collapsed outside of pre elements.
+func (p *Policy) Sanitize(src string, base *url.URL) string {
+	doc, err := html.Parse(strings.NewReader(src))
+	if err != nil {
+		// html.Parse fails only on read errors
+		return ""
+	}
+	return p.sanitize([]*html.Node{doc}, base)
+}
+
+// sanitize returns the HTML of the children of nodes allowed by p, with the
+// links resolved against base
+func (p *Policy) sanitize(nodes []*html.Node, base *url.URL) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			p.write(&b, child, base, false)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// write writes the sanitized n to b
+func (p *Policy) write(b *strings.Builder, n *html.Node, base *url.URL, pre bool) {
+	switch n.Type {
+	case html.TextNode:
+		text := n.Data
+		if !pre {
+			text = spacesRegexp.ReplaceAllString(text, " ")
+		}
+		b.WriteString(html.EscapeString(text))
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	tag := n.Data
+	if p.dropped(tag) {
+		return
+	}
+	attrs, allowed := p.Elements[tag]
+	if allowed {
+		b.WriteString("<" + tag)
+		for _, name := range attrs {
+			value, ok := attr(n, name)
+			if !ok || strings.HasPrefix(name, "on") || name == "style" {
+				continue
+			}
+			if containsString(urlAttributes, name) {
+				value, ok = p.resolve(value, base)
+				if !ok {
+					continue
+				}
+			}
+			b.WriteString(" " + name + `="` + html.EscapeString(value) + `"`)
+		}
+		b.WriteString(">")
+		if voidElements[tag] {
+			return
+		}
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		p.write(b, child, base, pre || tag == "pre")
+	}
+	if allowed {
+		b.WriteString("</" + tag + ">")
+	}
+}
+
+// dropped reports whether the elements named tag are removed with their
+// content
+func (p *Policy) dropped(tag string) bool {
+	return containsString(droppedElements, tag) || containsString(p.Drop, tag)
+}
+
+// resolve returns the absolute form of the link ref, or false if its scheme
+// is not allowed by p
+func (p *Policy) resolve(ref string, base *url.URL) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return "", false
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if scheme == "javascript" || scheme == "vbscript" || !containsString(p.Schemes, scheme) {
+		return "", false
+	}
+	return u.String(), true
+}
+
+func attr(n *html.Node, name string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == name {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func containsString(s []string, e string) bool {
+	for _, a := range s {
+		if a == e {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package newstojson
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestSanitize(t *testing.T) {
+	base, _ := url.Parse("http://www.di.univr.it/?ent=avviso&id=119016")
+	var tests = []struct {
+		html     string
+		expected string
+	}{
+		{`<p>Exam   on<br>Monday</p>`, `<p>Exam on<br>Monday</p>`},
+		{`<p class="x" style="color:red" onclick="alert(1)">Text</p>`, `<p>Text</p>`},
+		{`<script>alert(1)</script><style>p{}</style><iframe src="x"></iframe>Text`, `Text`},
+		{`<a href="?ent=avviso&id=1">Notice</a>`, `<a href="http://www.di.univr.it/?ent=avviso&amp;id=1">Notice</a>`},
+		{`<a href="/documenti/a.pdf" target="_blank">PDF</a>`, `<a href="http://www.di.univr.it/documenti/a.pdf">PDF</a>`},
+		{`<a href="javascript:alert(1)">Link</a>`, `<a>Link</a>`},
+		{`<a href="mailto:info@univr.it">Mail</a>`, `<a href="mailto:info@univr.it">Mail</a>`},
+		{`<img src="data:image/png;base64,AA" alt="x"><img src="/logo.png">`, `<img alt="x"><img src="http://www.di.univr.it/logo.png">`},
+		{`<div><font face="Arial"><span>Text</span></font></div>`, `Text`},
+		{`<table><tr><td colspan="2" width="10">1 &lt; 2</td></tr></table>`, `<table><tbody><tr><td colspan="2">1 &lt; 2</td></tr></tbody></table>`},
+		{`<pre>a  b</pre><!-- comment -->`, `<pre>a  b</pre>`},
+	}
+	for _, tt := range tests {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<div id="content">` + tt.html + `</div>`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := ContentPolicy.sanitize(doc.Find("#content").Nodes, base)
+		if res != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.html, tt.expected, res)
+		}
+	}
+}
+
+func TestPolicies(t *testing.T) {
+	base, _ := url.Parse("http://www.di.univr.it/?ent=anteprima&id=118037")
+	src := `<html><head><title>Preview</title></head><body>` +
+		`<p onmouseover="alert(1)">Marks <a href="/all.pdf">pdf</a> <img src="/logo.png"></p>` +
+		`<iframe src="http://example.com"></iframe></body></html>`
+	var tests = []struct {
+		policy   *Policy
+		expected string
+	}{
+		{&ContentPolicy, `<p>Marks <a href="http://www.di.univr.it/all.pdf">pdf</a> <img src="http://www.di.univr.it/logo.png"></p>`},
+		{&PreviewPolicy, `<p>Marks pdf </p>`},
+		{&TextPolicy, `Marks pdf`},
+		// Scripts, frames, event handlers and javascript: links are never kept
+		{&Policy{
+			Elements: map[string][]string{"p": {"onmouseover", "style"}, "iframe": {"src"}, "a": {"href"}},
+			Schemes:  []string{"http", "javascript"},
+		}, `<p>Marks <a href="http://www.di.univr.it/all.pdf">pdf</a> </p>`},
+	}
+	for i, tt := range tests {
+		if res := tt.policy.Sanitize(src, base); res != tt.expected {
+			t.Errorf("%d: expected %s, got %s", i, tt.expected, res)
+		}
+	}
+
+	custom := &Policy{Elements: map[string][]string{"a": {"href"}}, Schemes: []string{"http"}}
+	if res := custom.Sanitize(`<a href="javascript:alert(1)">x</a><a href="JavaScript:alert(1)">y</a>`, nil); res != `<a>x</a><a>y</a>` {
+		t.Error("Unexpected javascript: links", res)
+	}
+}
+
+func TestClientPolicies(t *testing.T) {
+	c := newFixtureClient(t)
+	c.ContentPolicy = &TextPolicy
+	c.PreviewPolicy = &TextPolicy
+
+	link, _ := url.Parse("http://www.di.univr.it/?ent=avviso&dest=165&rss=0&id=118037")
+	item, err := c.ParseFromLink(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.ContentHTML != "Si allegano gli esiti della prova scritta del 9 settembre 2016." {
+		t.Error("Unexpected content", item.ContentHTML)
+	}
+	if len(item.Attachments) != 1 || item.Attachments[0].Preview != "VR100001 - 27/30 VR100002 - 18/30" {
+		t.Error("Unexpected attachments", item.Attachments)
+	}
+
+	c.PreviewPolicy = nil
+	item, err = c.ParseFromLink(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(item.Attachments) != 1 || item.Attachments[0].Preview != "<p>VR100001 - 27/30</p> <p>VR100002 - 18/30</p>" {
+		t.Error("Unexpected attachments", item.Attachments)
+	}
+}
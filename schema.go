@@ -0,0 +1,216 @@
+package newstojson
+
+import (
+	"bytes"
+	_ "embed" // news.schema.json
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is the JSON Schema (draft 2020-12) of the JSON encoding of News
+//
+//go:embed news.schema.json
+var Schema []byte
+
+// SchemaError reports a document that does not match Schema
+type SchemaError struct {
+	Path    string // JSON pointer to the offending value
+	Message string
+}
+
+func (e *SchemaError) Error() string {
+	return "newstojson: " + e.Path + ": " + e.Message
+}
+
+// Validate checks that data is a JSON encoded News matching Schema
+func Validate(data []byte) error {
+	root, err := decodeJSON(Schema)
+	if err != nil {
+		return err
+	}
+	doc, err := decodeJSON(data)
+	if err != nil {
+		return err
+	}
+	v := validator{root: root.(map[string]interface{})}
+	return v.validate(v.root, doc, "")
+}
+
+// decodeJSON decodes data keeping the numbers as json.Number
+func decodeJSON(data []byte) (interface{}, error) {
+	var v interface{}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	err := d.Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// validator implements the subset of JSON Schema used by Schema: type,
+// properties, required, additionalProperties, items, minimum, enum, format
+// (date-time and uri) and local $ref.
+type validator struct {
+	root map[string]interface{}
+}
+
+func (v validator) validate(schema map[string]interface{}, doc interface{}, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		target, err := v.resolve(ref)
+		if err != nil {
+			return err
+		}
+		return v.validate(target, doc, path)
+	}
+
+	if t, ok := schema["type"].(string); ok && !hasType(doc, t) {
+		return &SchemaError{pointer(path), "expected " + t}
+	}
+
+	switch val := doc.(type) {
+	case map[string]interface{}:
+		return v.validateObject(schema, val, path)
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range val {
+				err := v.validate(items, item, path+"/"+strconv.Itoa(i))
+				if err != nil {
+					return err
+				}
+			}
+		}
+	case json.Number:
+		if min, ok := schema["minimum"].(json.Number); ok {
+			n, _ := val.Float64()
+			m, _ := min.Float64()
+			if n < m {
+				return &SchemaError{pointer(path), "expected a value >= " + min.String()}
+			}
+		}
+	case string:
+		if enum, ok := schema["enum"].([]interface{}); ok && !containsValue(enum, val) {
+			return &SchemaError{pointer(path), "unexpected value " + strconv.Quote(val)}
+		}
+		return checkFormat(schema, val, path)
+	}
+	return nil
+}
+
+func (v validator) validateObject(schema map[string]interface{}, doc map[string]interface{}, path string) error {
+	required, _ := schema["required"].([]interface{})
+	for _, name := range required {
+		if _, ok := doc[name.(string)]; !ok {
+			return &SchemaError{pointer(path), "missing property " + name.(string)}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	names := make([]string, 0, len(doc))
+	for name := range doc {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		property, ok := properties[name].(map[string]interface{})
+		if !ok {
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					return &SchemaError{pointer(path), "unexpected property " + name}
+				}
+				continue
+			case map[string]interface{}:
+				property = additional
+			default:
+				continue
+			}
+		}
+		err := v.validate(property, doc[name], path+"/"+name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve returns the schema a local reference like #/$defs/attachment points
+// to
+func (v validator) resolve(ref string) (map[string]interface{}, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("newstojson: unsupported schema reference %q", ref)
+	}
+	var cur interface{} = v.root
+	for _, token := range strings.Split(ref[2:], "/") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("newstojson: invalid schema reference %q", ref)
+		}
+		cur = m[token]
+	}
+	target, ok := cur.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("newstojson: invalid schema reference %q", ref)
+	}
+	return target, nil
+}
+
+func hasType(doc interface{}, t string) bool {
+	switch val := doc.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case string:
+		return t == "string"
+	case []interface{}:
+		return t == "array"
+	case map[string]interface{}:
+		return t == "object"
+	case json.Number:
+		if t == "number" {
+			return true
+		}
+		_, err := strconv.ParseInt(val.String(), 10, 64)
+		return t == "integer" && err == nil
+	}
+	return false
+}
+
+func checkFormat(schema map[string]interface{}, s string, path string) error {
+	switch schema["format"] {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return &SchemaError{pointer(path), "expected an RFC 3339 date-time"}
+		}
+	case "uri":
+		u, err := url.Parse(s)
+		if err != nil || !u.IsAbs() {
+			return &SchemaError{pointer(path), "expected an absolute URI"}
+		}
+	}
+	return nil
+}
+
+// pointer returns the JSON pointer of path, "" being the whole document
+func pointer(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+func containsValue(values []interface{}, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package newstojson
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// fullNews returns a news with every field set
+func fullNews() News {
+	link, _ := url.Parse("http://www.di.univr.it/?ent=avviso&id=119016&lang=eng")
+	return News{
+		ID:              119016,
+		Title:           "Exam results",
+		Description:     "Pubblicato da: Mario Rossi",
+		Content:         "The results are available.",
+		ContentHTML:     `<p>The results are <a href="http://www.di.univr.it/?ent=avviso&id=119017">available</a>.</p>`,
+		ContentMarkdown: "The results are [available](http://www.di.univr.it/?ent=avviso&id=119017).",
+		Link:            link,
+		Attachments:     []Attachment{{Title: "Results", Link: "http://www.di.univr.it/all.pdf", Preview: "<p>27/30</p>"}},
+		DipURL:          "http://www.di.univr.it",
+		Author:          "Mario Rossi",
+		PubTime:         time.Date(2016, 10, 31, 10, 26, 32, 0, time.UTC),
+		ModTime:         time.Date(2016, 11, 2, 9, 12, 5, 0, time.UTC),
+		Courses:         []string{"Algorithms (2016/2017)"},
+		DegreeIds:       []int{419, 417},
+		Links:           []Link{{Text: "ESSE3", URL: "https://esse3.univr.it", Kind: LinkInternal}},
+		Warnings:        []Warning{{Field: "attachments", Message: "preview not downloaded", Raw: "http://www.di.univr.it/all.pdf"}},
+		Translations: map[string]LocalizedText{
+			"eng": {Title: "Exam results", Content: "Risultati disponibili.", Courses: []string{"Algorithms (2016/2017)"}, Untranslated: true},
+			"ita": {Title: "Esiti esame", Content: "Risultati disponibili.", Courses: []string{"Algoritmi (2016/2017)"}},
+		},
+	}
+}
+
+func keys(m map[string]interface{}) []string {
+	var res []string
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// TestSchemaProperties fails when a field is added to or removed from the
+// JSON encoding without updating news.schema.json
+func TestSchemaProperties(t *testing.T) {
+	var schema struct {
+		Properties map[string]interface{}
+		Defs       struct {
+			Attachment struct {
+				Properties map[string]interface{}
+			}
+			Link struct {
+				Properties map[string]interface{}
+			}
+			Warning struct {
+				Properties map[string]interface{}
+			}
+			LocalizedText struct {
+				Properties map[string]interface{}
+			} `json:"localized_text"`
+		} `json:"$defs"`
+	}
+	err := json.Unmarshal(Schema, &schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := json.Marshal(fullNews())
+	var doc map[string]interface{}
+	json.Unmarshal(data, &doc)
+	if !reflect.DeepEqual(keys(doc), keys(schema.Properties)) {
+		t.Error("News: expected", keys(schema.Properties), "got", keys(doc))
+	}
+
+	attach := doc["attachments"].([]interface{})[0].(map[string]interface{})
+	if !reflect.DeepEqual(keys(attach), keys(schema.Defs.Attachment.Properties)) {
+		t.Error("Attachment: expected", keys(schema.Defs.Attachment.Properties), "got", keys(attach))
+	}
+
+	link := doc["links"].([]interface{})[0].(map[string]interface{})
+	if !reflect.DeepEqual(keys(link), keys(schema.Defs.Link.Properties)) {
+		t.Error("Link: expected", keys(schema.Defs.Link.Properties), "got", keys(link))
+	}
+
+	warning := doc["warnings"].([]interface{})[0].(map[string]interface{})
+	if !reflect.DeepEqual(keys(warning), keys(schema.Defs.Warning.Properties)) {
+		t.Error("Warning: expected", keys(schema.Defs.Warning.Properties), "got", keys(warning))
+	}
+
+	text := doc["translations"].(map[string]interface{})["eng"].(map[string]interface{})
+	if !reflect.DeepEqual(keys(text), keys(schema.Defs.LocalizedText.Properties)) {
+		t.Error("LocalizedText: expected", keys(schema.Defs.LocalizedText.Properties), "got", keys(text))
+	}
+}
+
+func TestValidate(t *testing.T) {
+	data, _ := json.Marshal(fullNews())
+	err := Validate(data)
+	if err != nil {
+		t.Error(err)
+	}
+
+	item := fullNews()
+	item.ModTime = time.Time{}
+	item.Attachments = nil
+	item.DegreeIds = nil
+	data, _ = json.Marshal(item)
+	err = Validate(data)
+	if err != nil {
+		t.Error(err)
+	}
+
+	var tests = []struct {
+		doc  string
+		path string
+	}{
+		{`[]`, "/"},
+		{`{"id":1}`, "/"},
+		{`{"id":-1,"title":"","content":"","link":"http://a.it/","attachments":[],"author":"","pub_time":"2016-10-31T10:26:32Z","courses":[],"degree_ids":[]}`, "/id"},
+		{`{"id":1,"title":"","content":"","link":"/?id=1","attachments":[],"author":"","pub_time":"2016-10-31T10:26:32Z","courses":[],"degree_ids":[]}`, "/link"},
+		{`{"id":1,"title":"","content":"","link":"http://a.it/","attachments":[],"author":"","pub_time":"31/10/2016","courses":[],"degree_ids":[]}`, "/pub_time"},
+		{`{"id":1,"title":"","content":"","link":"http://a.it/","attachments":[{"title":""}],"author":"","pub_time":"2016-10-31T10:26:32Z","courses":[],"degree_ids":[]}`, "/attachments/0"},
+		{`{"id":1,"title":"","content":"","link":"http://a.it/","attachments":[],"author":"","pub_time":"2016-10-31T10:26:32Z","courses":[],"degree_ids":[1.5]}`, "/degree_ids/0"},
+		{`{"id":1,"title":"","content":"","link":"http://a.it/","attachments":[],"author":"","pub_time":"2016-10-31T10:26:32Z","courses":[],"degree_ids":[],"Title":""}`, "/"},
+		{`{"id":1,"title":"","content":"","link":"http://a.it/","attachments":[],"author":"","pub_time":"2016-10-31T10:26:32Z","courses":[],"degree_ids":[],"translations":{"eng":{"title":""}}}`, "/translations/eng"},
+		{`{"id":1,"title":"","content":"","link":"http://a.it/","attachments":[],"author":"","pub_time":"2016-10-31T10:26:32Z","courses":[],"degree_ids":[],"links":[{"text":"","url":"http://a.it/","kind":"ftp"}]}`, "/links/0/kind"},
+	}
+	for _, tt := range tests {
+		err := Validate([]byte(tt.doc))
+		var serr *SchemaError
+		if !errors.As(err, &serr) {
+			t.Errorf("%s: expected a SchemaError, got %v", tt.doc, err)
+			continue
+		}
+		if serr.Path != tt.path {
+			t.Errorf("%s: expected error at %s, got %v", tt.doc, tt.path, err)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package newstojson
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+// sourceLanguage is the language the notices are written in. The pages in
+// other languages fall back to its text when a notice is not translated.
+const sourceLanguage = "ita"
+
+// LocalizedText is the text of a news in one language
+type LocalizedText struct {
+	Title   string   `json:"title"`
+	Content string   `json:"content"`
+	Courses []string `json:"courses"`
+
+	// Untranslated reports that the page repeats the Italian text
+	Untranslated bool `json:"untranslated,omitempty"`
+}
+
+// newsPageURL returns a copy of link asking for the page in lang
+func newsPageURL(link *url.URL, lang string) *url.URL {
+	res := *link
+	l, _ := url.ParseQuery(link.RawQuery)
+	l.Set("lang", lang)
+	res.RawQuery = l.Encode()
+	return &res
+}
+
+// setTranslations fills item.Translations with the text of item, in the
+// language of c, and of the pages in c.Languages
+func (c *Client) setTranslations(ctx context.Context, item *News) error {
+	item.Translations = map[string]LocalizedText{
+		c.language(): {Title: item.Title, Content: item.Content, Courses: item.Courses},
+	}
+	for _, lang := range c.Languages {
+		if _, ok := item.Translations[lang]; ok {
+			continue
+		}
+		link := newsPageURL(item.Link, lang)
+		doc, err := c.document(ctx, link.String())
+		if err != nil {
+			return err
+		}
+
+		page := &News{ID: item.ID, Link: link}
+		err = c.parseDocument(ctx, page, doc, false)
+		if err != nil {
+			return err
+		}
+		for _, w := range page.Warnings {
+			w.Message = "lang=" + lang + ": " + w.Message
+			item.Warnings = append(item.Warnings, w)
+		}
+		item.Translations[lang] = LocalizedText{Title: page.Title, Content: page.Content, Courses: page.Courses}
+	}
+
+	source, ok := item.Translations[sourceLanguage]
+	if !ok {
+		return nil
+	}
+	for lang, text := range item.Translations {
+		if lang != sourceLanguage && sameText(text, source) {
+			text.Untranslated = true
+			item.Translations[lang] = text
+		}
+	}
+	return nil
+}
+
+// sameText reports whether a and b have the same content, or the same title
+// if they have no content
+func sameText(a, b LocalizedText) bool {
+	normalize := func(s string) string {
+		return strings.ToLower(strings.Join(strings.Fields(s), " "))
+	}
+	if a.Content == "" && b.Content == "" {
+		return normalize(a.Title) == normalize(b.Title)
+	}
+	return normalize(a.Content) == normalize(b.Content)
+}
@@ -0,0 +1,73 @@
+package newstojson
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+)
+
+// Warning describes a problem found while parsing a news that did not stop
+// the parsing
+type Warning struct {
+	Field   string `json:"field"`         // JSON name of the field involved, like attachments
+	Message string `json:"message"`       // What went wrong
+	Raw     string `json:"raw,omitempty"` // Value found in the page, if any
+
+	err error // Cause of the warning, if any
+}
+
+func (w Warning) String() string {
+	if w.Raw == "" {
+		return w.Field + ": " + w.Message
+	}
+	return w.Field + " " + strconv.Quote(w.Raw) + ": " + w.Message
+}
+
+// warn records a warning on the news
+func (item *News) warn(field, message, raw string) {
+	item.Warnings = append(item.Warnings, Warning{Field: field, Message: message, Raw: raw})
+}
+
+// warnErr records a warning on the news caused by err. The message, if not
+// empty, is prefixed to the text of err.
+func (item *News) warnErr(field, message string, err error, raw string) {
+	if message != "" {
+		err = fmt.Errorf("%s: %w", message, err)
+	}
+	item.Warnings = append(item.Warnings, Warning{Field: field, Message: err.Error(), Raw: raw, err: err})
+}
+
+// addWarning appends w to warnings, if warnings is not nil
+func addWarning(warnings *[]Warning, w Warning) {
+	if warnings != nil {
+		*warnings = append(*warnings, w)
+	}
+}
+
+// report logs the warnings of item added since the first from and returns,
+// in strict mode, the first of them as a *FieldError
+func (c *Client) report(ctx context.Context, item *News, from int) error {
+	warnings := item.Warnings[from:]
+	for _, w := range warnings {
+		args := []interface{}{"news_id", item.ID, "field", w.Field}
+		if item.Link != nil {
+			args = append(args, "host", item.Link.Host)
+		}
+		if w.Raw != "" {
+			args = append(args, "raw", w.Raw)
+		}
+		c.log(ctx, slog.LevelWarn, w.Message, args...)
+	}
+
+	if !c.Strict || len(warnings) == 0 {
+		return nil
+	}
+	w := warnings[0]
+	err := w.err
+	if err == nil {
+		err = errors.New(w.Message)
+	}
+	return &FieldError{Field: w.Field, Raw: w.Raw, Err: err}
+}